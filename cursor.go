@@ -0,0 +1,255 @@
+package pyt
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultPageLimit is used when PageRequest.Limit is not set
+const DefaultPageLimit int = 20
+
+// MinCursorKeyLength is the shortest key that NewCursorCodec accepts, the
+// size of the sha256 hmac that signs cursors
+const MinCursorKeyLength int = 32
+
+var (
+	ErrBadCursor      error = errors.New("bad cursor")
+	ErrShortCursorKey error = errors.New("cursor key is too short")
+	ErrNilCursorCodec error = errors.New("a cursor codec is required to page")
+)
+
+type cursorPayload struct {
+	TimeCreated string   `json:"t"`
+	ID          string   `json:"id"`
+	Scope       []string `json:"s,omitempty"`
+}
+
+// CursorCodec converts a Cursor to and from an opaque string that can be
+// handed to api clients. The string is signed with the key, any changes
+// made to it are caught when it is decoded
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec creates a CursorCodec that signs cursors with key. The key
+// should be random and must be at least MinCursorKeyLength bytes
+func NewCursorCodec(key []byte) (*CursorCodec, error) {
+	if len(key) < MinCursorKeyLength {
+		return nil, fmt.Errorf(`%w: %d bytes, need %d`, ErrShortCursorKey, len(key), MinCursorKeyLength)
+	}
+
+	return &CursorCodec{
+		key: append([]byte{}, key...),
+	}, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// Encode converts the cursor into an opaque, signed, string. The scope,
+// like the node, edge type, and direction of a listing, is signed with the
+// cursor so that it cannot be used with a different listing
+func (c *CursorCodec) Encode(cursor Cursor, scope ...string) (string, error) {
+	payload, err := json.Marshal(cursorPayload{
+		TimeCreated: cursor.TimeCreated.T.UTC().Format(rfc3339Milli),
+		ID:          cursor.ID,
+		Scope:       scope,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode converts a string created by Encode back into a Cursor. ErrBadCursor
+// is returned if the string was not created with the same key and scope or
+// was altered
+func (c *CursorCodec) Decode(token string, scope ...string) (*Cursor, error) {
+	encoding := base64.RawURLEncoding
+
+	data, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrBadCursor
+	}
+
+	payload, err := encoding.DecodeString(data)
+	if err != nil {
+		return nil, errors.Join(ErrBadCursor, err)
+	}
+
+	mac, err := encoding.DecodeString(signature)
+	if err != nil {
+		return nil, errors.Join(ErrBadCursor, err)
+	}
+
+	if !hmac.Equal(mac, c.sign(payload)) {
+		return nil, ErrBadCursor
+	}
+
+	var decoded cursorPayload
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, errors.Join(ErrBadCursor, err)
+	}
+
+	if !slices.Equal(decoded.Scope, scope) {
+		return nil, ErrBadCursor
+	}
+
+	timeCreated, err := time.Parse(rfc3339Milli, decoded.TimeCreated)
+	if err != nil {
+		return nil, errors.Join(ErrBadCursor, err)
+	}
+
+	return &Cursor{
+		TimeCreated: Time{T: timeCreated.UTC()},
+		ID:          decoded.ID,
+	}, nil
+}
+
+// PageRequest asks for a single page of records. Cursor is the NextCursor
+// from the previous page and should be left empty to get the first page
+type PageRequest struct {
+	Limit           int
+	Cursor          string
+	IncludeInactive bool
+}
+
+// GenericEdgeNodePage is a single page of a relationship query. NextCursor
+// is empty when there are no more pages
+type GenericEdgeNodePage struct {
+	Items      GenericEdgeNodeSet
+	NextCursor string
+}
+
+// NodesOutRelatedByPage will return a page of the single out hop from nodeID
+// via the edgeType. See NodesGetRelatedByPage
+func NodesOutRelatedByPage(db Executor, codec *CursorCodec, nodeID, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return defaultGraph().NodesOutRelatedByPage(db, codec, nodeID, edgeType, filters, page)
+}
+
+func NodesOutRelatedByPageContext(ctx context.Context, db Executor, codec *CursorCodec, nodeID, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return defaultGraph().NodesOutRelatedByPageContext(ctx, db, codec, nodeID, edgeType, filters, page)
+}
+
+// NodesInRelatedByPage will return a page of the single in hop from nodeID
+// via the edgeType. See NodesGetRelatedByPage
+func NodesInRelatedByPage(db Executor, codec *CursorCodec, nodeID, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return defaultGraph().NodesInRelatedByPage(db, codec, nodeID, edgeType, filters, page)
+}
+
+func NodesInRelatedByPageContext(ctx context.Context, db Executor, codec *CursorCodec, nodeID, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return defaultGraph().NodesInRelatedByPageContext(ctx, db, codec, nodeID, edgeType, filters, page)
+}
+
+// NodesGetRelatedByPage will return a page of the single in or out hop from
+// nodeID via the edgeType. Records are ordered by the edge's time_created,
+// newest first, and then its id. Pages are keyed by the last edge of the
+// previous page so records added while paging do not shift later pages
+func NodesGetRelatedByPage(db Executor, codec *CursorCodec, nodeID, direction, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return defaultGraph().NodesGetRelatedByPage(db, codec, nodeID, direction, edgeType, filters, page)
+}
+
+func NodesGetRelatedByPageContext(ctx context.Context, db Executor, codec *CursorCodec, nodeID, direction, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return defaultGraph().NodesGetRelatedByPageContext(ctx, db, codec, nodeID, direction, edgeType, filters, page)
+}
+
+func NodesGetRelatedByPageWithTableName(db Executor, codec *CursorCodec, nodeTableName, edgeTableName, nodeID, direction, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodesGetRelatedByPage(db, codec, nodeID, direction, edgeType, filters, page)
+}
+
+// NodesOutRelatedByPage will return a page of the single out hop from nodeID
+// via the edgeType. See Graph.NodesGetRelatedByPage
+func (g *Graph) NodesOutRelatedByPage(db Executor, codec *CursorCodec, nodeID, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return g.NodesOutRelatedByPageContext(context.Background(), db, codec, nodeID, edgeType, filters, page)
+}
+
+func (g *Graph) NodesOutRelatedByPageContext(ctx context.Context, db Executor, codec *CursorCodec, nodeID, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return g.NodesGetRelatedByPageContext(ctx, db, codec, nodeID, "out", edgeType, filters, page)
+}
+
+// NodesInRelatedByPage will return a page of the single in hop from nodeID
+// via the edgeType. See Graph.NodesGetRelatedByPage
+func (g *Graph) NodesInRelatedByPage(db Executor, codec *CursorCodec, nodeID, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return g.NodesInRelatedByPageContext(context.Background(), db, codec, nodeID, edgeType, filters, page)
+}
+
+func (g *Graph) NodesInRelatedByPageContext(ctx context.Context, db Executor, codec *CursorCodec, nodeID, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return g.NodesGetRelatedByPageContext(ctx, db, codec, nodeID, "in", edgeType, filters, page)
+}
+
+// NodesGetRelatedByPage will return a page of the single in or out hop from
+// nodeID via the edgeType, newest edge first. The codec signs the cursors and
+// is required, ErrNilCursorCodec is returned without it
+func (g *Graph) NodesGetRelatedByPage(db Executor, codec *CursorCodec, nodeID, direction, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	return g.NodesGetRelatedByPageContext(context.Background(), db, codec, nodeID, direction, edgeType, filters, page)
+}
+
+func (g *Graph) NodesGetRelatedByPageContext(ctx context.Context, db Executor, codec *CursorCodec, nodeID, direction, edgeType string, filters *FilterSet, page PageRequest) (*GenericEdgeNodePage, error) {
+	if codec == nil {
+		return nil, ErrNilCursorCodec
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+
+	keyset := &Keyset{
+		Descending: true,
+	}
+
+	// the cursor only works for the listing that created it
+	scope := []string{g.edgeTableName, nodeID, direction, edgeType}
+
+	if page.Cursor != "" {
+		after, err := codec.Decode(page.Cursor, scope...)
+		if err != nil {
+			return nil, err
+		}
+
+		keyset.After = after
+	}
+
+	// one more record than the limit is requested to know if there is a next page
+	options := &QueryOptions{
+		Limit:           limit + 1,
+		Keyset:          keyset,
+		IncludeInactive: page.IncludeInactive,
+	}
+
+	set, err := g.NodesGetRelatedByWithOptionsContext(ctx, db, nodeID, direction, edgeType, filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := GenericEdgeNodePage{
+		Items: *set,
+	}
+
+	if len(resp.Items) > limit {
+		resp.Items = resp.Items[:limit]
+
+		resp.NextCursor, err = codec.Encode(resp.Items[limit-1].GenericEdge.Cursor(), scope...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &resp, nil
+}
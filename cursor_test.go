@@ -0,0 +1,40 @@
+package pyt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRelatedByPageRequiresCodec(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	page, err := g.NodesOutRelatedByPage(db, nil, "mark", "follows", nil, PageRequest{Limit: 2})
+	if !errors.Is(err, ErrNilCursorCodec) {
+		t.Fatalf("expected ErrNilCursorCodec, got %v", err)
+	}
+
+	if page != nil {
+		t.Fatalf("expected no page, got %+v", page)
+	}
+
+	// a cursor without a codec is rejected before it is read
+	_, err = g.NodesInRelatedByPage(db, nil, "ana", "follows", nil, PageRequest{Cursor: "not-a-cursor"})
+	if !errors.Is(err, ErrNilCursorCodec) {
+		t.Fatalf("expected ErrNilCursorCodec, got %v", err)
+	}
+
+	codec, err := NewCursorCodec([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err = g.NodesOutRelatedByPage(db, codec, "mark", "follows", nil, PageRequest{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Items) != 2 || page.NextCursor == "" {
+		t.Fatalf("expected two items and a next cursor, got %d and %q", len(page.Items), page.NextCursor)
+	}
+}
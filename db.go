@@ -1,11 +1,10 @@
 package pyt
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -18,106 +17,79 @@ var (
 	ErrBadUpsertQuery error = errors.New("bad upsert query")
 )
 
+// The package level functions below work against the default node and edge
+// tables, or the tables passed to their WithTableName twins. They are thin
+// wrappers around a Graph, see NewGraph for running multiple graphs in one
+// process. Every function that talks to the database has a Context twin,
+// NodeCreateContext, NodesGetByContext, etc., that passes ctx down to the
+// driver so that a cancelled ctx stops the query
+
 // BuildSchema does the work of scaffoling the database and
 // should be called when the connection is created.
 func BuildSchema(db *sql.DB) error {
 	return BuildSchemaWithTableNames(db, DefaultEdgeTableName, DefaultNodeTableName)
 }
 
-func BuildSchemaWithTableNames(db *sql.DB, edgeTableName, nodeTableName string) error {
-	queries := []string{
-		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
-			id TEXT NOT NULL UNIQUE PRIMARY KEY,
-			active INTEGER DEFAULT 1,
-			type TEXT NOT NULL,
-			properties TEXT,
-			time_created TEXT NOT NULL DEFAULT (strftime(%[2]s)),
-			time_updated TEXT NOT NULL DEFAULT (strftime(%[2]s))
-		) strict;`, nodeTableName, timeFormat),
-
-		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_time_updated_trigger
-		AFTER UPDATE ON %[1]s
-		BEGIN
-			UPDATE
-				 %[1]s
-			SET 
-				time_updated = STRFTIME(%[2]s, 'NOW')
-			WHERE id = NEW.id;
-		END;`, nodeTableName, timeFormat),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS id_idx ON %s(id);`, nodeTableName),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS type_idx ON %s(type);`, nodeTableName),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS time_created_idx ON %s(time_created);`, nodeTableName),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS time_updated_idx ON %s(time_updated);`, nodeTableName),
-
-		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
-			id TEXT NOT NULL UNIQUE PRIMARY KEY,
-			active INTEGER DEFAULT 1,
-			type TEXT NOT NULL,
-			in_id TEXT,
-			out_id TEXT,
-			properties TEXT,
-			time_created TEXT NOT NULL DEFAULT (strftime(%[3]s)),
-			time_updated TEXT NOT NULL DEFAULT (strftime(%[3]s)),
-			UNIQUE(in_id, out_id, properties) ON CONFLICT REPLACE,
-			FOREIGN KEY(in_id) REFERENCES %[2]s(id) ON DELETE CASCADE,
-			FOREIGN KEY(out_id) REFERENCES %[2]s(id) ON DELETE CASCADE
-		) strict;`, edgeTableName, nodeTableName, timeFormat),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS in_id_idx ON %s(in_id);`, edgeTableName),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS out_id_idx ON %s(out_id);`, edgeTableName),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS type_idx ON %s(type);`, edgeTableName),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS time_created_idx ON %s(time_created);`, edgeTableName),
-
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS time_updated_idx ON %s(time_updated);`, edgeTableName),
-
-		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_time_updated_trigger
-		AFTER UPDATE ON %[1]s
-		BEGIN
-			UPDATE
-				%[1]s 
-			SET 
-				time_updated = STRFTIME(%[2]s, 'NOW')
-			WHERE id = NEW.id;
-		END;`, edgeTableName, timeFormat),
-	}
+func BuildSchemaContext(ctx context.Context, db *sql.DB) error {
+	return NewGraph(db).BuildSchemaContext(ctx)
+}
 
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
+// SchemaVersion returns the version of the default graph's schema
+func SchemaVersion(db Executor) (int, error) {
+	return defaultGraph().SchemaVersion(db)
+}
 
-	for _, query := range queries {
-		_, err := tx.Exec(query)
-		if err != nil {
-			return errors.Join(err, tx.Rollback())
-		}
-	}
+func SchemaVersionContext(ctx context.Context, db Executor) (int, error) {
+	return defaultGraph().SchemaVersionContext(ctx, db)
+}
+
+// VerifyIndexes returns the names of the default graph's indexes that are
+// missing from the database
+func VerifyIndexes(db Executor) ([]string, error) {
+	return defaultGraph().VerifyIndexes(db)
+}
+
+func VerifyIndexesContext(ctx context.Context, db Executor) ([]string, error) {
+	return defaultGraph().VerifyIndexesContext(ctx, db)
+}
+
+// CreatePropertyIndex creates a property index on the default graph
+func CreatePropertyIndex(db Executor, index PropertyIndex) error {
+	return defaultGraph().CreatePropertyIndex(db, index)
+}
+
+func CreatePropertyIndexContext(ctx context.Context, db Executor, index PropertyIndex) error {
+	return defaultGraph().CreatePropertyIndexContext(ctx, db, index)
+}
+
+// PropertyIndexes lists the default graph's property indexes
+func PropertyIndexes(db Executor) ([]PropertyIndex, error) {
+	return defaultGraph().PropertyIndexes(db)
+}
 
-	return tx.Commit()
+func PropertyIndexesContext(ctx context.Context, db Executor) ([]PropertyIndex, error) {
+	return defaultGraph().PropertyIndexesContext(ctx, db)
+}
+
+func BuildSchemaWithTableNames(db *sql.DB, edgeTableName, nodeTableName string) error {
+	return NewGraph(db, WithNodeTableName(nodeTableName), WithEdgeTableName(edgeTableName)).BuildSchema()
 }
 
 // ResultToNode is a utility function that will convert an sql.Row into
 // a typed Node
-func ResultToNode[T any](row *sql.Row, tx *sql.Tx) (*Node[T], error) {
+func ResultToNode[T any](row *sql.Row) (*Node[T], error) {
 	entity := new(Node[T])
 	var newProperties string
 
 	err := row.Scan(&entity.entity.ID, &entity.entity.Active, &entity.entity.Type, &newProperties, &entity.entity.TimeCreated, &entity.entity.TimeUpdated)
 	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
+		return nil, err
 
 	}
 
 	newProps, err := PropertiesToType[T]([]byte(newProperties))
 	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
+		return nil, err
 
 	}
 
@@ -128,7 +100,7 @@ func ResultToNode[T any](row *sql.Row, tx *sql.Tx) (*Node[T], error) {
 
 // RowsToNode is a utility method that is used to convert an sql.Rows instance
 // into a typed NodeSet
-func RowsToNode[T any](rows *sql.Rows, tx *sql.Tx) (*NodeSet[T], error) {
+func RowsToNode[T any](rows *sql.Rows) (*NodeSet[T], error) {
 	var nodes NodeSet[T]
 
 	for rows.Next() {
@@ -136,26 +108,28 @@ func RowsToNode[T any](rows *sql.Rows, tx *sql.Tx) (*NodeSet[T], error) {
 		var properties string
 		err := rows.Scan(&newNode.entity.ID, &newNode.entity.Active, &newNode.entity.Type, &properties, &newNode.entity.TimeCreated, &newNode.entity.TimeUpdated)
 		if err != nil {
-			tx.Rollback()
-			return nil, errors.Join(err, tx.Rollback())
+			return nil, err
 		}
 
 		props, err := PropertiesToType[T]([]byte(properties))
 		if err != nil {
-			tx.Rollback()
-			return nil, errors.Join(err, tx.Rollback())
+			return nil, err
 		}
 
 		newNode.Properties = *props
 		nodes = append(nodes, *newNode)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return &nodes, nil
 }
 
 // RowsToEdge is a utility method that is used to convert an sql.Rows instance
 // into a typed EdgeSet
-func RowsToEdge[T any](rows *sql.Rows, tx *sql.Tx) (*EdgeSet[T], error) {
+func RowsToEdge[T any](rows *sql.Rows) (*EdgeSet[T], error) {
 	var nodes EdgeSet[T]
 
 	for rows.Next() {
@@ -163,91 +137,49 @@ func RowsToEdge[T any](rows *sql.Rows, tx *sql.Tx) (*EdgeSet[T], error) {
 		var properties string
 		err := rows.Scan(&newEdge.entity.ID, &newEdge.entity.Active, &newEdge.entity.Type, &newEdge.InID, &newEdge.OutID, &properties, &newEdge.entity.TimeCreated, &newEdge.entity.TimeUpdated)
 		if err != nil {
-			tx.Rollback()
-			return nil, errors.Join(err, tx.Rollback())
+			return nil, err
 		}
 
 		props, err := PropertiesToType[T]([]byte(properties))
 		if err != nil {
-			tx.Rollback()
-			return nil, errors.Join(err, tx.Rollback())
+			return nil, err
 		}
 
 		newEdge.Properties = *props
 		nodes = append(nodes, *newEdge)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return &nodes, nil
 }
 
 // NodeCreate will add a node to the database
-func NodeCreate[T any](tx *sql.Tx, newNode Node[T]) (*Node[T], error) {
-	return NodeCreateWithTableName[T](tx, DefaultNodeTableName, newNode)
+func NodeCreate[T any](db Executor, newNode Node[T]) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).Create(db, newNode)
 }
 
-func NodeCreateWithTableName[T any](tx *sql.Tx, nodeTableName string, newNode Node[T]) (*Node[T], error) {
-	nodes, err := NodesCreateWithTableName(tx, nodeTableName, newNode)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	if nodes == nil || len(*nodes) == 0 {
-		return nil, sql.ErrNoRows
-	}
+func NodeCreateContext[T any](ctx context.Context, db Executor, newNode Node[T]) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).CreateContext(ctx, db, newNode)
+}
 
-	return &(*nodes)[0], nil
+func NodeCreateWithTableName[T any](db Executor, nodeTableName string, newNode Node[T]) (*Node[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).Create(db, newNode)
 }
 
 // NodesCreate will add mulitple nodes to the database
-func NodesCreate[T any](tx *sql.Tx, newNodes ...Node[T]) (*NodeSet[T], error) {
-	return NodesCreateWithTableName[T](tx, DefaultNodeTableName, newNodes...)
+func NodesCreate[T any](db Executor, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).CreateMany(db, newNodes...)
 }
 
-func NodesCreateWithTableName[T any](tx *sql.Tx, nodeTableName string, newNodes ...Node[T]) (*NodeSet[T], error) {
-	var err error
-
-	values := make([]string, len(newNodes))
-	params := []any{}
-
-	for i := 0; i < len(newNodes); i++ {
-		values[i] = "(?, ?, ?, ?)"
-		properties, err := json.Marshal(newNodes[i].Properties)
-		if err != nil {
-			return nil, errors.Join(err, tx.Rollback())
-		}
-		params = append(params, newNodes[i].entity.ID, newNodes[i].entity.Active, newNodes[i].entity.Type, string(properties))
-	}
-
-	query := fmt.Sprintf(`
-	INSERT INTO
-		%s
-		(id, active, type, properties)
-	VALUES
-		%s
-	RETURNING
-		*
-	`, nodeTableName, strings.Join(values, ","))
-
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-	defer stmt.Close()
-
-	res, err := stmt.Query(params...)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	defer res.Close()
-
-	nodes, err := RowsToNode[T](res, tx)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	return nodes, nil
+func NodesCreateContext[T any](ctx context.Context, db Executor, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).CreateManyContext(ctx, db, newNodes...)
+}
 
+func NodesCreateWithTableName[T any](db Executor, nodeTableName string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).CreateMany(db, newNodes...)
 }
 
 // NodeUpsert will execute an upsert query based on the conflictColumns and the
@@ -265,21 +197,16 @@ func NodesCreateWithTableName[T any](tx *sql.Tx, nodeTableName string, newNodes
 //
 // you would pass in "type, properties->'username'" as the conflictColumns
 // and, in this case, "type='user'" as the conflictClause
-func NodeUpsert[T any](tx *sql.Tx, conflictColumns string, conflictClause string, newNode Node[T]) (*Node[T], error) {
-	return NodeUpsertWithTableName[T](tx, DefaultNodeTableName, conflictColumns, conflictClause, newNode)
+func NodeUpsert[T any](db Executor, conflictColumns string, conflictClause string, newNode Node[T]) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).Upsert(db, conflictColumns, conflictClause, newNode)
 }
 
-func NodeUpsertWithTableName[T any](tx *sql.Tx, nodeTableName, conflictColumns, conflictClause string, newNode Node[T]) (*Node[T], error) {
-	nodes, err := NodesUpsertWithTableName[T](tx, nodeTableName, conflictColumns, conflictClause, newNode)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	if nodes == nil || len(*nodes) == 0 {
-		return nil, sql.ErrNoRows
-	}
+func NodeUpsertContext[T any](ctx context.Context, db Executor, conflictColumns string, conflictClause string, newNode Node[T]) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).UpsertContext(ctx, db, conflictColumns, conflictClause, newNode)
+}
 
-	return nodes.First(), nil
+func NodeUpsertWithTableName[T any](db Executor, nodeTableName, conflictColumns, conflictClause string, newNode Node[T]) (*Node[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).Upsert(db, conflictColumns, conflictClause, newNode)
 }
 
 // NodesUpsert will execute an upsert query based on the conflictColumns and the
@@ -297,305 +224,298 @@ func NodeUpsertWithTableName[T any](tx *sql.Tx, nodeTableName, conflictColumns,
 //
 // you would pass in "type, properties->'username'" as the conflicedColumns
 // and, in this case, "type='user'" as the conflictClause
-func NodesUpsert[T any](tx *sql.Tx, conflictColumns, conflictClause string, newNodes ...Node[T]) (*NodeSet[T], error) {
-	return NodesUpsertWithTableName[T](tx, DefaultNodeTableName, conflictColumns, conflictClause, newNodes...)
+func NodesUpsert[T any](db Executor, conflictColumns, conflictClause string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).UpsertMany(db, conflictColumns, conflictClause, newNodes...)
 }
 
-func NodesUpsertWithTableName[T any](tx *sql.Tx, nodeTableName, conflictColumns, conflictClause string, newNodes ...Node[T]) (*NodeSet[T], error) {
-	if len(conflictColumns) == 0 {
-		return nil, ErrBadUpsertQuery
-	}
-
-	var err error
-	values := make([]string, len(newNodes))
-	params := []any{}
-
-	for i := 0; i < len(newNodes); i++ {
-		values[i] = "(?, ?, ?, ?)"
-		properties, err := json.Marshal(newNodes[i].Properties)
-		if err != nil {
-			return nil, errors.Join(err, tx.Rollback())
-		}
-		params = append(params, newNodes[i].entity.ID, newNodes[i].entity.Active, newNodes[i].entity.Type, string(properties))
-	}
-
-	if strings.TrimSpace(conflictClause) != "" {
-		conflictClause = "WHERE " + conflictClause
-	}
-
-	query := fmt.Sprintf(`
-	INSERT INTO
-		%s
-		(id, active, type, properties)
-	VALUES
-		%s
-	ON CONFLICT (%s) %s DO UPDATE SET
-		active = excluded.active,
-		properties = excluded.properties
-	RETURNING
-		*
-	`, nodeTableName, strings.Join(values, ","), conflictColumns, conflictClause)
-
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func NodesUpsertContext[T any](ctx context.Context, db Executor, conflictColumns, conflictClause string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).UpsertManyContext(ctx, db, conflictColumns, conflictClause, newNodes...)
+}
 
-	defer stmt.Close()
+func NodesUpsertWithTableName[T any](db Executor, nodeTableName, conflictColumns, conflictClause string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).UpsertMany(db, conflictColumns, conflictClause, newNodes...)
+}
 
-	res, err := stmt.Query(params...)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+// NodeUpsertByIndex will upsert a node using a unique property index
+// instead of raw conflict columns and clause
+func NodeUpsertByIndex[T any](db Executor, indexName string, newNode Node[T]) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).UpsertByIndex(db, indexName, newNode)
+}
 
-	defer res.Close()
+func NodeUpsertByIndexContext[T any](ctx context.Context, db Executor, indexName string, newNode Node[T]) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).UpsertByIndexContext(ctx, db, indexName, newNode)
+}
 
-	nodes, err := RowsToNode[T](res, tx)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+// NodesUpsertByIndex will upsert multiple nodes using a unique property index
+func NodesUpsertByIndex[T any](db Executor, indexName string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).UpsertManyByIndex(db, indexName, newNodes...)
+}
 
-	return nodes, nil
+func NodesUpsertByIndexContext[T any](ctx context.Context, db Executor, indexName string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).UpsertManyByIndexContext(ctx, db, indexName, newNodes...)
 }
 
 // NodeUpdate updates a node's properties. updatedNode.ID must exist in the database
-func NodeUpdate[T any](tx *sql.Tx, updatedNode Node[T], withReturn bool) (*Node[T], error) {
-	return NodeUpdateWithTableName[T](tx, DefaultNodeTableName, updatedNode, withReturn)
-}
-
-func NodeUpdateWithTableName[T any](tx *sql.Tx, nodeTableName string, updatedNode Node[T], withReturn bool) (*Node[T], error) {
-	var err error
-
-	query := fmt.Sprintf(`
-	UPDATE
-		%s
-	SET
-		active = ?,
-		properties = ?
-	WHERE
-		id = ?
-	RETURNING
-		*
-	`, nodeTableName)
-	properties, err := json.Marshal(updatedNode.Properties)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	_, err = tx.Exec(query, updatedNode.entity.Active, string(properties), updatedNode.ID)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	if !withReturn {
-		return nil, nil
-	}
-
-	node, err := NodeGetByID[T](tx, updatedNode.ID)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	return node, nil
+func NodeUpdate[T any](db Executor, updatedNode Node[T], withReturn bool) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).Update(db, updatedNode, withReturn)
 }
 
-// NodeGetByID retrieves and typed node by its id
-func NodeGetByID[T any](tx *sql.Tx, id string) (*Node[T], error) {
-	return NodeGetByIDWithTableName[T](tx, DefaultNodeTableName, id)
+func NodeUpdateContext[T any](ctx context.Context, db Executor, updatedNode Node[T], withReturn bool) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).UpdateContext(ctx, db, updatedNode, withReturn)
 }
 
-func NodeGetByIDWithTableName[T any](tx *sql.Tx, nodeTableName, id string) (*Node[T], error) {
-	fil := FilterSet{
-		NewFilter("id", id),
-	}
-
-	return NodeGetBy[T](tx, fil)
+func NodeUpdateWithTableName[T any](db Executor, nodeTableName string, updatedNode Node[T], withReturn bool) (*Node[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).Update(db, updatedNode, withReturn)
 }
 
-// NodeGetBy retuns a single typed node by filters
-func NodeGetBy[T any](tx *sql.Tx, filters FilterSet) (*Node[T], error) {
-	return NodeGetByWithTableName[T](tx, DefaultNodeTableName, filters)
+// NodeGetByID retrieves and typed node by its id. Only an active node is
+// returned, see NodeGetByIDWithOptions to include an inactive one
+func NodeGetByID[T any](db Executor, id string) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).GetByID(db, id)
 }
 
-func NodeGetByWithTableName[T any](tx *sql.Tx, nodeTableName string, filters FilterSet) (*Node[T], error) {
-	nodes, err := NodesGetByWithTableName[T](tx, nodeTableName, &filters)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func NodeGetByIDContext[T any](ctx context.Context, db Executor, id string) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).GetByIDContext(ctx, db, id)
+}
 
-	if nodes == nil || len(*nodes) == 0 {
-		return nil, sql.ErrNoRows
-	}
+func NodeGetByIDWithTableName[T any](db Executor, nodeTableName, id string) (*Node[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).GetByID(db, id)
+}
 
-	return &(*nodes)[0], nil
+// NodeGetByIDWithOptions retrieves a typed node by its id, the options can
+// include an inactive node
+func NodeGetByIDWithOptions[T any](db Executor, id string, options *QueryOptions) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).GetByIDWithOptions(db, id, options)
 }
 
-// NodesGetBy will return a typed NodeSet and can be extended using a FilterSet
-func NodesGetBy[T any](tx *sql.Tx, filters *FilterSet) (*NodeSet[T], error) {
-	return NodesGetByWithTableName[T](tx, DefaultNodeTableName, filters)
+func NodeGetByIDWithOptionsContext[T any](ctx context.Context, db Executor, id string, options *QueryOptions) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).GetByIDWithOptionsContext(ctx, db, id, options)
 }
 
-func NodesGetByWithTableName[T any](tx *sql.Tx, nodeTableName string, filters *FilterSet) (*NodeSet[T], error) {
-	params := []any{}
-	var where string
-	var err error
+func NodeGetByIDWithOptionsAndTableName[T any](db Executor, nodeTableName, id string, options *QueryOptions) (*Node[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).GetByIDWithOptions(db, id, options)
+}
 
-	if filters != nil {
-		clasuses := filters.Build(&params)
-		if clasuses != "" {
-			where = fmt.Sprintf(`WHERE
-			%s`, clasuses)
-		}
-	}
+// NodeGetBy retuns a single typed node by filters
+func NodeGetBy[T any](db Executor, filters FilterSet) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).GetBy(db, filters)
+}
 
-	query := fmt.Sprintf(`
-	SELECT
-		*
-	FROM
-		%s
-	%s
-	`, nodeTableName, where)
+func NodeGetByContext[T any](ctx context.Context, db Executor, filters FilterSet) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).GetByContext(ctx, db, filters)
+}
 
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func NodeGetByWithTableName[T any](db Executor, nodeTableName string, filters FilterSet) (*Node[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).GetBy(db, filters)
+}
 
-	defer stmt.Close()
+// NodeGetByWithOptions returns a single typed node by filters, the options can
+// include inactive nodes
+func NodeGetByWithOptions[T any](db Executor, filters FilterSet, options *QueryOptions) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).GetByWithOptions(db, filters, options)
+}
 
-	res, err := stmt.Query(params...)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func NodeGetByWithOptionsContext[T any](ctx context.Context, db Executor, filters FilterSet, options *QueryOptions) (*Node[T], error) {
+	return Nodes[T](defaultGraph()).GetByWithOptionsContext(ctx, db, filters, options)
+}
 
-	defer res.Close()
+func NodeGetByWithOptionsAndTableName[T any](db Executor, nodeTableName string, filters FilterSet, options *QueryOptions) (*Node[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).GetByWithOptions(db, filters, options)
+}
 
-	nodes, err := RowsToNode[T](res, tx)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+// NodesGetBy will return a typed NodeSet and can be extended using a FilterSet.
+// Only active nodes are returned, see NodesGetByWithOptions to include inactive ones
+func NodesGetBy[T any](db Executor, filters *FilterSet) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).GetManyBy(db, filters)
+}
 
-	return nodes, nil
+func NodesGetByContext[T any](ctx context.Context, db Executor, filters *FilterSet) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).GetManyByContext(ctx, db, filters)
 }
 
-func NodeDeleteByIDs(tx *sql.Tx, nodeIDs ...string) (int64, error) {
-	return NodeDeleteByIDsWithTableName(tx, DefaultNodeTableName, nodeIDs...)
+func NodesGetByWithTableName[T any](db Executor, nodeTableName string, filters *FilterSet) (*NodeSet[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).GetManyBy(db, filters)
 }
 
-func NodeDeleteByIDsWithTableName(tx *sql.Tx, nodeTableName string, nodeIDs ...string) (int64, error) {
-	params := []any{}
-	holders := []string{}
+// NodesGetByWithOptions will return a typed NodeSet that can be extended using
+// a FilterSet and ordered, limited, or paged with QueryOptions
+func NodesGetByWithOptions[T any](db Executor, filters *FilterSet, options *QueryOptions) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).GetManyByWithOptions(db, filters, options)
+}
 
-	for _, id := range nodeIDs {
-		holders = append(holders, "?")
-		params = append(params, id)
-	}
+func NodesGetByWithOptionsContext[T any](ctx context.Context, db Executor, filters *FilterSet, options *QueryOptions) (*NodeSet[T], error) {
+	return Nodes[T](defaultGraph()).GetManyByWithOptionsContext(ctx, db, filters, options)
+}
 
-	query := fmt.Sprintf(`
-	DELETE FROM
-		%s
-	WHERE id IN (%s)
-	`, nodeTableName, strings.Join(holders, ", "))
+func NodesGetByWithOptionsAndTableName[T any](db Executor, nodeTableName string, filters *FilterSet, options *QueryOptions) (*NodeSet[T], error) {
+	return Nodes[T](tableGraph(nodeTableName, DefaultEdgeTableName)).GetManyByWithOptions(db, filters, options)
+}
 
-	res, err := tx.Exec(query, params...)
-	if err != nil {
-		return 0, errors.Join(err, tx.Rollback())
-	}
+// NodeDeleteByIDs will delete the nodes and, by way of the foreign keys,
+// every edge connected to them
+func NodeDeleteByIDs(db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeDeleteByIDs(db, nodeIDs...)
+}
 
-	count, err := res.RowsAffected()
-	if err != nil {
-		return 0, errors.Join(err, tx.Rollback())
-	}
+func NodeDeleteByIDsContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeDeleteByIDsContext(ctx, db, nodeIDs...)
+}
 
-	return count, nil
+func NodeDeleteByIDsWithTableName(db Executor, nodeTableName string, nodeIDs ...string) (int64, error) {
+	return tableGraph(nodeTableName, DefaultEdgeTableName).NodeDeleteByIDs(db, nodeIDs...)
 }
 
 // NodesOutRelatedBy will do a single out hop from nodeID via the edgeType
 // can be extended with a FilterSet the edge table is aliased as e, and the
 // node table is aliased as n
-func NodesOutRelatedBy(tx *sql.Tx, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
-	return NodesGetRelatedBy(tx, nodeID, "out", edgeType, filters)
+func NodesOutRelatedBy(db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesOutRelatedBy(db, nodeID, edgeType, filters)
 }
 
-func NodesOutRelatedByWithTableName(tx *sql.Tx, nodeTableName, edgeTableName, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
-	return NodesGetRelatedByWithTableName(tx, nodeTableName, edgeTableName, nodeID, "out", edgeType, filters)
+func NodesOutRelatedByContext(ctx context.Context, db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesOutRelatedByContext(ctx, db, nodeID, edgeType, filters)
+}
+
+func NodesOutRelatedByWithTableName(db Executor, nodeTableName, edgeTableName, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodesOutRelatedBy(db, nodeID, edgeType, filters)
 }
 
 // NodesInRelatedBy will do a single in hop from nodeID via the edgeType
 // can be extended with a FilterSet the edge table is aliased as e, and the
 // node table is aliased as n
-func NodesInRelatedBy(tx *sql.Tx, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
-	return NodesGetRelatedBy(tx, nodeID, "in", edgeType, filters)
+func NodesInRelatedBy(db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesInRelatedBy(db, nodeID, edgeType, filters)
+}
+
+func NodesInRelatedByContext(ctx context.Context, db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesInRelatedByContext(ctx, db, nodeID, edgeType, filters)
 }
 
-func NodesInRelatedByWithTableName(tx *sql.Tx, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
-	return NodesGetRelatedBy(tx, nodeID, "in", edgeType, filters)
+func NodesInRelatedByWithTableNames(db Executor, nodeTableName, edgeTableName, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodesInRelatedBy(db, nodeID, edgeType, filters)
+}
+
+// NodesInRelatedByWithTableName never took the table names and always
+// queried the default tables, it is kept with its original signature
+//
+// Deprecated: use NodesInRelatedByWithTableNames or a Graph
+func NodesInRelatedByWithTableName(db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesInRelatedBy(db, nodeID, edgeType, filters)
 }
 
 // NodesGetRelatedBy will do a single in or out hop from nodeID via the edgeType
 // can be extended with a FilterSet the edge table is aliased as e, and the
-// node table is aliased as n
-func NodesGetRelatedBy(tx *sql.Tx, nodeID, direction, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
-	return NodesGetRelatedByWithTableName(tx, DefaultNodeTableName, DefaultEdgeTableName, nodeID, direction, edgeType, filters)
+// node table is aliased as n. Only records where both the edge and node are
+// active are returned
+func NodesGetRelatedBy(db Executor, nodeID, direction, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesGetRelatedBy(db, nodeID, direction, edgeType, filters)
 }
 
-func NodesGetRelatedByWithTableName(tx *sql.Tx, nodeTableName, edgeTableName, nodeID, direction, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
-	var err error
+func NodesGetRelatedByContext(ctx context.Context, db Executor, nodeID, direction, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesGetRelatedByContext(ctx, db, nodeID, direction, edgeType, filters)
+}
 
-	edgeWhere := "in_id"
-	edgeJoin := "out_id"
+func NodesGetRelatedByWithTableName(db Executor, nodeTableName, edgeTableName, nodeID, direction, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodesGetRelatedBy(db, nodeID, direction, edgeType, filters)
+}
 
-	if direction == "in" {
-		edgeJoin = "in_id"
-		edgeWhere = "out_id"
-	}
+// NodesGetRelatedByWithOptions will do a single in or out hop from nodeID via
+// the edgeType. The result can be ordered, limited, or paged with QueryOptions
+// whose columns refer to the edge table
+func NodesGetRelatedByWithOptions(db Executor, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesGetRelatedByWithOptions(db, nodeID, direction, edgeType, filters, options)
+}
 
-	params := []any{nodeID, edgeType}
-
-	query := fmt.Sprintf(`
-	SELECT
-		e.id as edge_id,
-		e.type as edge_type,
-		e.in_id as edge_in_id,
-		e.out_id as edge_out_id,
-		e.properties as edge_properties,
-		e.time_created as edge_time_created,
-		e.time_updated as edge_time_updated,
-		n.id as node_id,
-		n.type as node_type,
-		n.properties as node_properties,
-		n.time_created as node_time_created,
-		n.time_updated as node_time_updated
-	FROM
-		%s e
-	JOIN
-		%s n ON n.id = e.%s
-	WHERE
-		e.%s = ?
-	AND
-		e.type = ?
-	`, edgeTableName, nodeTableName, edgeJoin, edgeWhere)
-
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func NodesGetRelatedByWithOptionsContext(ctx context.Context, db Executor, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*GenericEdgeNodeSet, error) {
+	return defaultGraph().NodesGetRelatedByWithOptionsContext(ctx, db, nodeID, direction, edgeType, filters, options)
+}
 
-	defer stmt.Close()
+func NodesGetRelatedByWithOptionsAndTableName(db Executor, nodeTableName, edgeTableName, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*GenericEdgeNodeSet, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodesGetRelatedByWithOptions(db, nodeID, direction, edgeType, filters, options)
+}
 
-	rows, err := stmt.Query(params...)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+// NodesOutRelated will do a single out hop from nodeID via the edgeType and
+// scan the edges and nodes directly into their types. It can be extended with
+// a FilterSet the edge table is aliased as e, and the node table is aliased as n
+func NodesOutRelated[NodeType any, EdgeType any](db Executor, nodeID, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](defaultGraph()).Out(db, nodeID, edgeType, filters)
+}
+
+func NodesOutRelatedContext[NodeType any, EdgeType any](ctx context.Context, db Executor, nodeID, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](defaultGraph()).OutContext(ctx, db, nodeID, edgeType, filters)
+}
 
-	defer rows.Close()
+// NodesInRelated will do a single in hop from nodeID via the edgeType and
+// scan the edges and nodes directly into their types. It can be extended with
+// a FilterSet the edge table is aliased as e, and the node table is aliased as n
+func NodesInRelated[NodeType any, EdgeType any](db Executor, nodeID, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](defaultGraph()).In(db, nodeID, edgeType, filters)
+}
 
+func NodesInRelatedContext[NodeType any, EdgeType any](ctx context.Context, db Executor, nodeID, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](defaultGraph()).InContext(ctx, db, nodeID, edgeType, filters)
+}
+
+// NodesGetRelated will do a single in or out hop from nodeID via the edgeType
+// and scan the edges and nodes directly into their types. It can be extended
+// with a FilterSet the edge table is aliased as e, and the node table is
+// aliased as n. Only records where both the edge and node are active are returned
+func NodesGetRelated[NodeType any, EdgeType any](db Executor, nodeID, direction, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](defaultGraph()).Get(db, nodeID, direction, edgeType, filters)
+}
+
+func NodesGetRelatedContext[NodeType any, EdgeType any](ctx context.Context, db Executor, nodeID, direction, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](defaultGraph()).GetContext(ctx, db, nodeID, direction, edgeType, filters)
+}
+
+func NodesGetRelatedWithTableName[NodeType any, EdgeType any](db Executor, nodeTableName, edgeTableName, nodeID, direction, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](tableGraph(nodeTableName, edgeTableName)).Get(db, nodeID, direction, edgeType, filters)
+}
+
+// NodesGetRelatedWithOptions is NodesGetRelated whose result can be ordered,
+// limited, or paged with QueryOptions whose columns refer to the edge table
+func NodesGetRelatedWithOptions[NodeType any, EdgeType any](db Executor, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](defaultGraph()).GetWithOptions(db, nodeID, direction, edgeType, filters, options)
+}
+
+func NodesGetRelatedWithOptionsContext[NodeType any, EdgeType any](ctx context.Context, db Executor, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](defaultGraph()).GetWithOptionsContext(ctx, db, nodeID, direction, edgeType, filters, options)
+}
+
+func NodesGetRelatedWithOptionsAndTableName[NodeType any, EdgeType any](db Executor, nodeTableName, edgeTableName, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return Related[NodeType, EdgeType](tableGraph(nodeTableName, edgeTableName)).GetWithOptions(db, nodeID, direction, edgeType, filters, options)
+}
+
+// edgeNodeColumns returns the select list used by queries that pair an edge
+// with the node on one end of it. The order of the columns matches what
+// RowsToGenericEdgeNode and RowsToNodeEdge expect to scan
+func edgeNodeColumns(edgeAlias, nodeAlias string) string {
+	return fmt.Sprintf(`%[1]s.id as edge_id,
+		%[1]s.active as edge_active,
+		%[1]s.type as edge_type,
+		%[1]s.in_id as edge_in_id,
+		%[1]s.out_id as edge_out_id,
+		%[1]s.properties as edge_properties,
+		%[1]s.time_created as edge_time_created,
+		%[1]s.time_updated as edge_time_updated,
+		%[2]s.id as node_id,
+		%[2]s.active as node_active,
+		%[2]s.type as node_type,
+		%[2]s.properties as node_properties,
+		%[2]s.time_created as node_time_created,
+		%[2]s.time_updated as node_time_updated`, edgeAlias, nodeAlias)
+}
+
+// RowsToGenericEdgeNode is a utility method that is used to convert an sql.Rows
+// instance selected with edgeNodeColumns into a GenericEdgeNodeSet
+func RowsToGenericEdgeNode(rows *sql.Rows) (*GenericEdgeNodeSet, error) {
 	var resp GenericEdgeNodeSet
 
 	for rows.Next() {
 		rec := GenericEdgeNode{}
 		err := rows.Scan(
 			&rec.GenericEdge.entity.ID,
+			&rec.GenericEdge.entity.Active,
 			&rec.GenericEdge.entity.Type,
 			&rec.GenericEdge.InID,
 			&rec.GenericEdge.OutID,
@@ -603,372 +523,270 @@ func NodesGetRelatedByWithTableName(tx *sql.Tx, nodeTableName, edgeTableName, no
 			&rec.GenericEdge.entity.TimeCreated,
 			&rec.GenericEdge.entity.TimeUpdated,
 			&rec.GenericNode.entity.ID,
+			&rec.GenericNode.entity.Active,
 			&rec.GenericNode.entity.Type,
 			&rec.GenericNode.Properties,
 			&rec.GenericNode.entity.TimeCreated,
 			&rec.GenericNode.entity.TimeUpdated,
 		)
 		if err != nil {
-			return nil, errors.Join(err, tx.Rollback())
+			return nil, err
 		}
 
 		resp = append(resp, rec)
 	}
 
-	return &resp, nil
-}
-
-// EdgeCreate will add an edge to the database. The InID and OutID nodes
-// must already exist in the database or are apart of the current transaction
-func EdgeCreate[T any](tx *sql.Tx, newEdge Edge[T]) (*Edge[T], error) {
-	return EdgeCreateWithTableName[T](tx, DefaultEdgeTableName, newEdge)
-}
-
-func EdgeCreateWithTableName[T any](tx *sql.Tx, edgeTableName string, newEdge Edge[T]) (*Edge[T], error) {
-	edges, err := EdgesCreateWithTableName[T](tx, edgeTableName, newEdge)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	if edges == nil || len(*edges) == 0 {
-		return nil, sql.ErrNoRows
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return &(*edges)[0], nil
+	return &resp, nil
 }
 
-// EdgesCreate will add mulitple edges to the database. The InID and OutID nodes
-// for each edge must already exist in the database or are apart of the current transaction
-func EdgesCreate[T any](tx *sql.Tx, newEdges ...Edge[T]) (*EdgeSet[T], error) {
-	return EdgesCreateWithTableName[T](tx, DefaultEdgeTableName, newEdges...)
-}
+// RowsToNodeEdge is a utility method that is used to convert an sql.Rows
+// instance selected with edgeNodeColumns into a typed TypedNodeEdgeSet
+func RowsToNodeEdge[NodeType any, EdgeType any](rows *sql.Rows) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	var resp TypedNodeEdgeSet[NodeType, EdgeType]
 
-func EdgesCreateWithTableName[T any](tx *sql.Tx, edgeTableName string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
-	var err error
+	for rows.Next() {
+		edge := new(Edge[EdgeType])
+		node := new(Node[NodeType])
+		var edgeProperties, nodeProperties string
+		err := rows.Scan(
+			&edge.entity.ID,
+			&edge.entity.Active,
+			&edge.entity.Type,
+			&edge.InID,
+			&edge.OutID,
+			&edgeProperties,
+			&edge.entity.TimeCreated,
+			&edge.entity.TimeUpdated,
+			&node.entity.ID,
+			&node.entity.Active,
+			&node.entity.Type,
+			&nodeProperties,
+			&node.entity.TimeCreated,
+			&node.entity.TimeUpdated,
+		)
+		if err != nil {
+			return nil, err
+		}
 
-	values := make([]string, len(newEdges))
-	params := []any{}
+		edgeProps, err := PropertiesToType[EdgeType]([]byte(edgeProperties))
+		if err != nil {
+			return nil, err
+		}
 
-	for i := 0; i < len(newEdges); i++ {
-		values[i] = "(?, ?, ?, ?, ?, ?)"
-		properties, err := json.Marshal(newEdges[i].Properties)
+		nodeProps, err := PropertiesToType[NodeType]([]byte(nodeProperties))
 		if err != nil {
-			return nil, errors.Join(err, tx.Rollback())
+			return nil, err
 		}
 
-		params = append(params, newEdges[i].entity.ID, newEdges[i].entity.Active, newEdges[i].entity.Type, newEdges[i].InID, newEdges[i].OutID, string(properties))
-	}
+		edge.Properties = *edgeProps
+		node.Properties = *nodeProps
 
-	query := fmt.Sprintf(`
-	INSERT INTO
-		%s
-		(id, active, type, in_id, out_id, properties)
-	VALUES
-		%s
-	RETURNING
-		*
-	`, edgeTableName, strings.Join(values, ","))
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
+		resp = append(resp, TypedNodeEdge[NodeType, EdgeType]{
+			Node: node,
+			Edge: edge,
+		})
 	}
 
-	defer stmt.Close()
-
-	res, err := stmt.Query(params...)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	defer res.Close()
+	return &resp, nil
+}
 
-	edges, err := RowsToEdge[T](res, tx)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+// EdgeCreate will add an edge to the database. The InID and OutID nodes
+// must already exist in the database or are apart of the current transaction
+func EdgeCreate[T any](db Executor, newEdge Edge[T]) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).Create(db, newEdge)
+}
 
-	return edges, nil
+func EdgeCreateContext[T any](ctx context.Context, db Executor, newEdge Edge[T]) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).CreateContext(ctx, db, newEdge)
 }
 
-// EdgeUpdate will update the properties on an existing edge
-func EdgeUpdate[T any](tx *sql.Tx, updatedEdge Edge[T], withReturn bool) (*Edge[T], error) {
-	return EdgeUpdateWithTableName[T](tx, DefaultEdgeTableName, updatedEdge, withReturn)
-}
-
-func EdgeUpdateWithTableName[T any](tx *sql.Tx, edgeTableName string, updatedEdge Edge[T], withReturn bool) (*Edge[T], error) {
-	var err error
-
-	query := fmt.Sprintf(`
-	UPDATE
-		%s
-	SET
-		active = ?,
-		properties = ?
-	WHERE
-		id = ?
-	RETURNING
-		*
-	`, edgeTableName)
-	properties, err := json.Marshal(updatedEdge.Properties)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func EdgeCreateWithTableName[T any](db Executor, edgeTableName string, newEdge Edge[T]) (*Edge[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).Create(db, newEdge)
+}
 
-	_, err = tx.Exec(query, updatedEdge.entity.Active, string(properties), updatedEdge.ID)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+// EdgesCreate will add mulitple edges to the database. The InID and OutID nodes
+// for each edge must already exist in the database or are apart of the current transaction
+func EdgesCreate[T any](db Executor, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).CreateMany(db, newEdges...)
+}
 
-	if !withReturn {
-		return nil, nil
-	}
+func EdgesCreateContext[T any](ctx context.Context, db Executor, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).CreateManyContext(ctx, db, newEdges...)
+}
 
-	edge, err := EdgeGetByID[T](tx, updatedEdge.ID)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func EdgesCreateWithTableName[T any](db Executor, edgeTableName string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).CreateMany(db, newEdges...)
+}
 
-	return edge, nil
+// EdgeUpdate will update the properties on an existing edge
+func EdgeUpdate[T any](db Executor, updatedEdge Edge[T], withReturn bool) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).Update(db, updatedEdge, withReturn)
 }
 
-func EdgeUpsert[T any](tx *sql.Tx, conflictColumns string, conflictClause string, newEdge Edge[T]) (*Edge[T], error) {
-	return EdgeUpsertWithTableName[T](tx, DefaultEdgeTableName, conflictColumns, conflictClause, newEdge)
+func EdgeUpdateContext[T any](ctx context.Context, db Executor, updatedEdge Edge[T], withReturn bool) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).UpdateContext(ctx, db, updatedEdge, withReturn)
 }
 
-func EdgeUpsertWithTableName[T any](tx *sql.Tx, edgetTableName, conflictColumns, conflictClause string, newEdge Edge[T]) (*Edge[T], error) {
-	edges, err := EdgesUpsertWithTableName[T](tx, edgetTableName, conflictColumns, conflictClause, newEdge)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func EdgeUpdateWithTableName[T any](db Executor, edgeTableName string, updatedEdge Edge[T], withReturn bool) (*Edge[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).Update(db, updatedEdge, withReturn)
+}
 
-	if edges == nil || len(*edges) == 0 {
-		return nil, sql.ErrNoRows
-	}
+func EdgeUpsert[T any](db Executor, conflictColumns string, conflictClause string, newEdge Edge[T]) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).Upsert(db, conflictColumns, conflictClause, newEdge)
+}
+
+func EdgeUpsertContext[T any](ctx context.Context, db Executor, conflictColumns string, conflictClause string, newEdge Edge[T]) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).UpsertContext(ctx, db, conflictColumns, conflictClause, newEdge)
+}
 
-	return edges.First(), nil
+func EdgeUpsertWithTableName[T any](db Executor, edgetTableName, conflictColumns, conflictClause string, newEdge Edge[T]) (*Edge[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgetTableName)).Upsert(db, conflictColumns, conflictClause, newEdge)
 }
 
 // EdgesUpsert will execute an upsert query based on the conflictColumns and the
 // conflictCluase values
-func EdgesUpsert[T any](tx *sql.Tx, conflictColumns, conflictClause string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
-	return EdgesUpsertWithTableName[T](tx, DefaultEdgeTableName, conflictColumns, conflictClause, newEdges...)
+func EdgesUpsert[T any](db Executor, conflictColumns, conflictClause string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).UpsertMany(db, conflictColumns, conflictClause, newEdges...)
 }
 
-func EdgesUpsertWithTableName[T any](tx *sql.Tx, edgeTableName, conflictColumns, conflictClause string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
-	if len(conflictColumns) == 0 {
-		return nil, ErrBadUpsertQuery
-	}
-
-	var err error
-	values := make([]string, len(newEdges))
-	params := []any{}
-
-	for i := 0; i < len(newEdges); i++ {
-		values[i] = "(?, ?, ?, ?, ?, ?)"
-		properties, err := json.Marshal(newEdges[i].Properties)
-		if err != nil {
-			return nil, errors.Join(err, tx.Rollback())
-		}
+func EdgesUpsertContext[T any](ctx context.Context, db Executor, conflictColumns, conflictClause string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).UpsertManyContext(ctx, db, conflictColumns, conflictClause, newEdges...)
+}
 
-		params = append(params, newEdges[i].entity.ID, newEdges[i].entity.Active, newEdges[i].entity.Type, newEdges[i].InID, newEdges[i].OutID, string(properties))
-	}
+func EdgesUpsertWithTableName[T any](db Executor, edgeTableName, conflictColumns, conflictClause string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).UpsertMany(db, conflictColumns, conflictClause, newEdges...)
+}
 
-	if strings.TrimSpace(conflictClause) != "" {
-		conflictClause = "WHERE " + conflictClause
-	}
+// EdgeUpsertByIndex will upsert an edge using a unique property index
+// instead of raw conflict columns and clause
+func EdgeUpsertByIndex[T any](db Executor, indexName string, newEdge Edge[T]) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).UpsertByIndex(db, indexName, newEdge)
+}
 
-	query := fmt.Sprintf(`
-		INSERT INTO
-			%s
-			(id, active, type, in_id, out_id, properties)
-		VALUES
-			%s
-		ON CONFLICT (%s) %s DO UPDATE SET
-			active = excluded.active,
-			properties = excluded.properties
-		RETURNING
-			*
-		`, edgeTableName, strings.Join(values, ","), conflictColumns, conflictClause)
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func EdgeUpsertByIndexContext[T any](ctx context.Context, db Executor, indexName string, newEdge Edge[T]) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).UpsertByIndexContext(ctx, db, indexName, newEdge)
+}
 
-	defer stmt.Close()
+// EdgesUpsertByIndex will upsert multiple edges using a unique property index
+func EdgesUpsertByIndex[T any](db Executor, indexName string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).UpsertManyByIndex(db, indexName, newEdges...)
+}
 
-	res, err := stmt.Query(params...)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func EdgesUpsertByIndexContext[T any](ctx context.Context, db Executor, indexName string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).UpsertManyByIndexContext(ctx, db, indexName, newEdges...)
+}
 
-	defer res.Close()
+// EdgeGetByID will return a typed edge by its id. Only an active edge is
+// returned, see EdgeGetByIDWithOptions to include an inactive one
+func EdgeGetByID[T any](db Executor, id string) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).GetByID(db, id)
+}
 
-	edges, err := RowsToEdge[T](res, tx)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
+func EdgeGetByIDContext[T any](ctx context.Context, db Executor, id string) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).GetByIDContext(ctx, db, id)
+}
 
-	return edges, nil
+func EdgeGetByIDWithTableName[T any](db Executor, edgeTableName, id string) (*Edge[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).GetByID(db, id)
 }
 
-// EdgeGetByID will return a typed edge by its id
-func EdgeGetByID[T any](tx *sql.Tx, id string) (*Edge[T], error) {
-	return EdgeGetByIDWithTableName[T](tx, DefaultEdgeTableName, id)
+// EdgeGetByIDWithOptions retrieves a typed edge by its id, the options can
+// include an inactive edge
+func EdgeGetByIDWithOptions[T any](db Executor, id string, options *QueryOptions) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).GetByIDWithOptions(db, id, options)
 }
 
-func EdgeGetByIDWithTableName[T any](tx *sql.Tx, edgeTableName, id string) (*Edge[T], error) {
-	fil := FilterSet{
-		NewFilter("id", id),
-	}
+func EdgeGetByIDWithOptionsContext[T any](ctx context.Context, db Executor, id string, options *QueryOptions) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).GetByIDWithOptionsContext(ctx, db, id, options)
+}
 
-	return EdgeGetByWithTableName[T](tx, edgeTableName, fil)
+func EdgeGetByIDWithOptionsAndTableName[T any](db Executor, edgeTableName, id string, options *QueryOptions) (*Edge[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).GetByIDWithOptions(db, id, options)
 }
 
 // EdgeGetByID will return a single typed edge by its id
-func EdgeGetBy[T any](tx *sql.Tx, filters FilterSet) (*Edge[T], error) {
-	return EdgeGetByWithTableName[T](tx, DefaultEdgeTableName, filters)
+func EdgeGetBy[T any](db Executor, filters FilterSet) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).GetBy(db, filters)
 }
 
-func EdgeGetByWithTableName[T any](tx *sql.Tx, edgeTableName string, filters FilterSet) (*Edge[T], error) {
-	edges, err := EdgesGetByWithTableName[T](tx, edgeTableName, &filters)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	if edges == nil || len(*edges) == 0 {
-		return nil, sql.ErrNoRows
-	}
-
-	return &(*edges)[0], nil
+func EdgeGetByContext[T any](ctx context.Context, db Executor, filters FilterSet) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).GetByContext(ctx, db, filters)
 }
 
-// EdgesGetBy will return a typed EdgeSet and can be extended using a FilterSet
-func EdgesGetBy[T any](tx *sql.Tx, filters *FilterSet) (*EdgeSet[T], error) {
-	return EdgesGetByWithTableName[T](tx, DefaultEdgeTableName, filters)
+func EdgeGetByWithTableName[T any](db Executor, edgeTableName string, filters FilterSet) (*Edge[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).GetBy(db, filters)
 }
 
-func EdgesGetByWithTableName[T any](tx *sql.Tx, edgeTableName string, filters *FilterSet) (*EdgeSet[T], error) {
-	params := []any{}
-	var where string
-	var err error
-
-	if filters != nil {
-		clasuses := filters.Build(&params)
-		if clasuses != "" {
-			where = fmt.Sprintf(`WHERE
-			%s`, clasuses)
-		}
-	}
-
-	query := fmt.Sprintf(`
-	SELECT
-		*
-	FROM
-		%s
-	%s
-	`, edgeTableName, where)
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	defer stmt.Close()
-
-	res, err := stmt.Query(params...)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	defer res.Close()
-
-	edges, err := RowsToEdge[T](res, tx)
-	if err != nil {
-		return nil, errors.Join(err, tx.Rollback())
-	}
-
-	return edges, nil
+// EdgeGetByWithOptions returns a single typed edge by filters, the options can
+// include inactive edges
+func EdgeGetByWithOptions[T any](db Executor, filters FilterSet, options *QueryOptions) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).GetByWithOptions(db, filters, options)
 }
 
-func EdgeDeleteByIDs(tx *sql.Tx, edgeIDs ...string) (int64, error) {
-	return EdgeDeleteByIDsWithTableName(tx, DefaultEdgeTableName, edgeIDs...)
+func EdgeGetByWithOptionsContext[T any](ctx context.Context, db Executor, filters FilterSet, options *QueryOptions) (*Edge[T], error) {
+	return Edges[T](defaultGraph()).GetByWithOptionsContext(ctx, db, filters, options)
 }
 
-func EdgeDeleteByIDsWithTableName(tx *sql.Tx, edgeTableName string, edgeIDs ...string) (int64, error) {
-	params := []any{}
-	holders := []string{}
-
-	for _, id := range edgeIDs {
-		holders = append(holders, "?")
-		params = append(params, id)
-	}
-
-	query := fmt.Sprintf(`
-	DELETE FROM
-		%s
-	WHERE id IN (%s)
-	`, edgeTableName, strings.Join(holders, ", "))
-
-	res, err := tx.Exec(query, params...)
-	if err != nil {
-		return 0, errors.Join(err, tx.Rollback())
-	}
-
-	count, err := res.RowsAffected()
-	if err != nil {
-		return 0, errors.Join(err, tx.Rollback())
-	}
-
-	return count, nil
+func EdgeGetByWithOptionsAndTableName[T any](db Executor, edgeTableName string, filters FilterSet, options *QueryOptions) (*Edge[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).GetByWithOptions(db, filters, options)
 }
 
-func EdgeDeleteByNodeIDs(tx *sql.Tx, inIDs []string, outIDs []string) (int64, error) {
-	return EdgeDeleteByNodeIDsWithTableName(tx, DefaultEdgeTableName, inIDs, outIDs)
+// EdgesGetBy will return a typed EdgeSet and can be extended using a FilterSet.
+// Only active edges are returned, see EdgesGetByWithOptions to include inactive ones
+func EdgesGetBy[T any](db Executor, filters *FilterSet) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).GetManyBy(db, filters)
 }
 
-func EdgeDeleteByNodeIDsWithTableName(tx *sql.Tx, edgeTableName string, inIDs []string, outIDs []string) (int64, error) {
-	params := []any{}
-	where := "WHERE "
+func EdgesGetByContext[T any](ctx context.Context, db Executor, filters *FilterSet) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).GetManyByContext(ctx, db, filters)
+}
 
-	if len(outIDs) > 0 {
-		outHolders := []string{}
+func EdgesGetByWithTableName[T any](db Executor, edgeTableName string, filters *FilterSet) (*EdgeSet[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).GetManyBy(db, filters)
+}
 
-		for _, id := range outIDs {
-			outHolders = append(outHolders, "?")
-			params = append(params, id)
-		}
+// EdgesGetByWithOptions will return a typed EdgeSet that can be extended using
+// a FilterSet and ordered, limited, or paged with QueryOptions
+func EdgesGetByWithOptions[T any](db Executor, filters *FilterSet, options *QueryOptions) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).GetManyByWithOptions(db, filters, options)
+}
 
-		where = fmt.Sprintf(`%s %s.out_id IN (%s)`, where, edgeTableName, strings.Join(outHolders, ", "))
-	}
+func EdgesGetByWithOptionsContext[T any](ctx context.Context, db Executor, filters *FilterSet, options *QueryOptions) (*EdgeSet[T], error) {
+	return Edges[T](defaultGraph()).GetManyByWithOptionsContext(ctx, db, filters, options)
+}
 
-	if len(inIDs) > 0 {
-		inHolders := []string{}
-		for _, id := range inIDs {
-			inHolders = append(inHolders, "?")
-			params = append(params, id)
-		}
+func EdgesGetByWithOptionsAndTableName[T any](db Executor, edgeTableName string, filters *FilterSet, options *QueryOptions) (*EdgeSet[T], error) {
+	return Edges[T](tableGraph(DefaultNodeTableName, edgeTableName)).GetManyByWithOptions(db, filters, options)
+}
 
-		if len(outIDs) > 0 {
-			where = where + " OR "
-		}
+func EdgeDeleteByIDs(db Executor, edgeIDs ...string) (int64, error) {
+	return defaultGraph().EdgeDeleteByIDs(db, edgeIDs...)
+}
 
-		where = fmt.Sprintf(`%s %s.in_id IN (%s)`, where, edgeTableName, strings.Join(inHolders, ", "))
-	}
+func EdgeDeleteByIDsContext(ctx context.Context, db Executor, edgeIDs ...string) (int64, error) {
+	return defaultGraph().EdgeDeleteByIDsContext(ctx, db, edgeIDs...)
+}
 
-	query := fmt.Sprintf(`
-	DELETE FROM
-		%s
-	%s
-	`, edgeTableName, where)
+func EdgeDeleteByIDsWithTableName(db Executor, edgeTableName string, edgeIDs ...string) (int64, error) {
+	return tableGraph(DefaultNodeTableName, edgeTableName).EdgeDeleteByIDs(db, edgeIDs...)
+}
 
-	res, err := tx.Exec(query, params...)
-	if err != nil {
-		return 0, errors.Join(err, tx.Rollback())
-	}
+func EdgeDeleteByNodeIDs(db Executor, inIDs []string, outIDs []string) (int64, error) {
+	return defaultGraph().EdgeDeleteByNodeIDs(db, inIDs, outIDs)
+}
 
-	count, err := res.RowsAffected()
-	if err != nil {
-		return 0, errors.Join(err, tx.Rollback())
-	}
+func EdgeDeleteByNodeIDsContext(ctx context.Context, db Executor, inIDs []string, outIDs []string) (int64, error) {
+	return defaultGraph().EdgeDeleteByNodeIDsContext(ctx, db, inIDs, outIDs)
+}
 
-	return count, nil
+func EdgeDeleteByNodeIDsWithTableName(db Executor, edgeTableName string, inIDs []string, outIDs []string) (int64, error) {
+	return tableGraph(DefaultNodeTableName, edgeTableName).EdgeDeleteByNodeIDs(db, inIDs, outIDs)
 }
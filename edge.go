@@ -0,0 +1,485 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// EdgeStore reads and writes typed edges in a Graph's edge table
+type EdgeStore[T any] struct {
+	graph *Graph
+}
+
+// Edges returns an EdgeStore for edges whose properties are T
+func Edges[T any](g *Graph) EdgeStore[T] {
+	return EdgeStore[T]{
+		graph: g,
+	}
+}
+
+// edgeType returns the edge's type or the type that T is registered as in
+// the graph's registry when the edge does not have one
+func (s EdgeStore[T]) edgeType(edge Edge[T]) string {
+	if edge.Type != "" {
+		return edge.Type
+	}
+
+	name, _ := EdgeTypeOf[T](s.graph.registry)
+
+	return name
+}
+
+// Create will add an edge to the database. The InID and OutID nodes
+// must already exist in the database or are apart of the current transaction
+func (s EdgeStore[T]) Create(db Executor, newEdge Edge[T]) (*Edge[T], error) {
+	return s.CreateContext(context.Background(), db, newEdge)
+}
+
+func (s EdgeStore[T]) CreateContext(ctx context.Context, db Executor, newEdge Edge[T]) (*Edge[T], error) {
+	edges, err := s.CreateManyContext(ctx, db, newEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	if edges == nil || len(*edges) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &(*edges)[0], nil
+}
+
+// CreateMany will add mulitple edges to the database. The InID and OutID nodes
+// for each edge must already exist in the database or are apart of the current transaction
+// and their types must match the endpoints registered for the edge's type.
+// Edges that break the graph's EdgeUniqueness are handled by its EdgeConflict
+func (s EdgeStore[T]) CreateMany(db Executor, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return s.CreateManyContext(context.Background(), db, newEdges...)
+}
+
+func (s EdgeStore[T]) CreateManyContext(ctx context.Context, db Executor, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	err := s.validate(newEdges...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(newEdges))
+	ends := make([]edgeEnds, len(newEdges))
+	params := []any{}
+
+	for i := 0; i < len(newEdges); i++ {
+		values[i] = "(?, ?, ?, ?, ?, ?)"
+		properties, err := json.Marshal(newEdges[i].Properties)
+		if err != nil {
+			return nil, err
+		}
+
+		edgeType := s.edgeType(newEdges[i])
+		ends[i] = edgeEnds{edgeType, newEdges[i].InID, newEdges[i].OutID}
+		params = append(params, newEdges[i].entity.ID, newEdges[i].entity.Active, edgeType, newEdges[i].InID, newEdges[i].OutID, string(properties))
+	}
+
+	err = s.graph.checkEdgeEndpoints(ctx, db, ends)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.graph.resolveEdgePolicy(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	conflict, err := s.graph.edgeConflictClause(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO
+		%s
+		(id, active, type, in_id, out_id, properties)
+	VALUES
+		%s
+	%s
+	RETURNING
+		*
+	`, s.graph.edgeTableName, strings.Join(values, ","), conflict)
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	edges, err := RowsToEdge[T](res)
+	if err != nil {
+		return nil, err
+	}
+
+	return edges, nil
+}
+
+// Update will update the properties on an existing edge
+func (s EdgeStore[T]) Update(db Executor, updatedEdge Edge[T], withReturn bool) (*Edge[T], error) {
+	return s.UpdateContext(context.Background(), db, updatedEdge, withReturn)
+}
+
+func (s EdgeStore[T]) UpdateContext(ctx context.Context, db Executor, updatedEdge Edge[T], withReturn bool) (*Edge[T], error) {
+	err := s.validate(updatedEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		active = ?,
+		properties = ?
+	WHERE
+		id = ?
+	RETURNING
+		*
+	`, s.graph.edgeTableName)
+	properties, err := json.Marshal(updatedEdge.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.ExecContext(ctx, query, updatedEdge.entity.Active, string(properties), updatedEdge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !withReturn {
+		return nil, nil
+	}
+
+	// the edge is loaded even if the update soft deleted it
+	edges, err := s.GetManyByWithOptionsContext(ctx, db, &FilterSet{NewFilter("id", updatedEdge.ID)}, &QueryOptions{IncludeInactive: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(*edges) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return edges.First(), nil
+}
+
+// Upsert will execute an upsert query based on the conflictColumns and the
+// conflictCluase values
+func (s EdgeStore[T]) Upsert(db Executor, conflictColumns, conflictClause string, newEdge Edge[T]) (*Edge[T], error) {
+	return s.UpsertContext(context.Background(), db, conflictColumns, conflictClause, newEdge)
+}
+
+func (s EdgeStore[T]) UpsertContext(ctx context.Context, db Executor, conflictColumns, conflictClause string, newEdge Edge[T]) (*Edge[T], error) {
+	edges, err := s.UpsertManyContext(ctx, db, conflictColumns, conflictClause, newEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	if edges == nil || len(*edges) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return edges.First(), nil
+}
+
+// UpsertMany will execute an upsert query based on the conflictColumns and the
+// conflictCluase values. Like CreateMany, the types of each edge's nodes
+// must match the endpoints registered for the edge's type
+func (s EdgeStore[T]) UpsertMany(db Executor, conflictColumns, conflictClause string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return s.UpsertManyContext(context.Background(), db, conflictColumns, conflictClause, newEdges...)
+}
+
+func (s EdgeStore[T]) UpsertManyContext(ctx context.Context, db Executor, conflictColumns, conflictClause string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	if len(conflictColumns) == 0 {
+		return nil, ErrBadUpsertQuery
+	}
+
+	err := s.validate(newEdges...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(newEdges))
+	ends := make([]edgeEnds, len(newEdges))
+	params := []any{}
+
+	for i := 0; i < len(newEdges); i++ {
+		values[i] = "(?, ?, ?, ?, ?, ?)"
+		properties, err := json.Marshal(newEdges[i].Properties)
+		if err != nil {
+			return nil, err
+		}
+
+		edgeType := s.edgeType(newEdges[i])
+		ends[i] = edgeEnds{edgeType, newEdges[i].InID, newEdges[i].OutID}
+		params = append(params, newEdges[i].entity.ID, newEdges[i].entity.Active, edgeType, newEdges[i].InID, newEdges[i].OutID, string(properties))
+	}
+
+	err = s.graph.checkEdgeEndpoints(ctx, db, ends)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(conflictClause) != "" {
+		conflictClause = "WHERE " + conflictClause
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO
+			%s
+			(id, active, type, in_id, out_id, properties)
+		VALUES
+			%s
+		ON CONFLICT (%s) %s DO UPDATE SET
+			active = excluded.active,
+			properties = excluded.properties
+		RETURNING
+			*
+		`, s.graph.edgeTableName, strings.Join(values, ","), conflictColumns, conflictClause)
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	edges, err := RowsToEdge[T](res)
+	if err != nil {
+		return nil, err
+	}
+
+	return edges, nil
+}
+
+// UpsertByIndex will upsert the edge using the conflict target of a unique
+// PropertyIndex. See CreatePropertyIndex
+func (s EdgeStore[T]) UpsertByIndex(db Executor, indexName string, newEdge Edge[T]) (*Edge[T], error) {
+	return s.UpsertByIndexContext(context.Background(), db, indexName, newEdge)
+}
+
+func (s EdgeStore[T]) UpsertByIndexContext(ctx context.Context, db Executor, indexName string, newEdge Edge[T]) (*Edge[T], error) {
+	edges, err := s.UpsertManyByIndexContext(ctx, db, indexName, newEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	if edges == nil || len(*edges) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return edges.First(), nil
+}
+
+// UpsertManyByIndex will upsert the edges using the conflict target of a
+// unique PropertyIndex. The edges are checked against the registered
+// endpoints by UpsertMany
+func (s EdgeStore[T]) UpsertManyByIndex(db Executor, indexName string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	return s.UpsertManyByIndexContext(context.Background(), db, indexName, newEdges...)
+}
+
+func (s EdgeStore[T]) UpsertManyByIndexContext(ctx context.Context, db Executor, indexName string, newEdges ...Edge[T]) (*EdgeSet[T], error) {
+	conflictColumns, conflictClause, err := s.graph.upsertTarget(ctx, db, indexName, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UpsertManyContext(ctx, db, conflictColumns, conflictClause, newEdges...)
+}
+
+// GetByID will return a typed edge by its id. Only an active edge is
+// returned, see GetByIDWithOptions to include an inactive one
+func (s EdgeStore[T]) GetByID(db Executor, id string) (*Edge[T], error) {
+	return s.GetByIDContext(context.Background(), db, id)
+}
+
+func (s EdgeStore[T]) GetByIDContext(ctx context.Context, db Executor, id string) (*Edge[T], error) {
+	return s.GetByIDWithOptionsContext(ctx, db, id, nil)
+}
+
+// GetByIDWithOptions retrieves a typed edge by its id, the options can
+// include an inactive edge
+func (s EdgeStore[T]) GetByIDWithOptions(db Executor, id string, options *QueryOptions) (*Edge[T], error) {
+	return s.GetByIDWithOptionsContext(context.Background(), db, id, options)
+}
+
+func (s EdgeStore[T]) GetByIDWithOptionsContext(ctx context.Context, db Executor, id string, options *QueryOptions) (*Edge[T], error) {
+	fil := FilterSet{
+		NewFilter("id", id),
+	}
+
+	return s.GetByWithOptionsContext(ctx, db, fil, options)
+}
+
+// GetBy will return a single typed edge by filters
+func (s EdgeStore[T]) GetBy(db Executor, filters FilterSet) (*Edge[T], error) {
+	return s.GetByContext(context.Background(), db, filters)
+}
+
+func (s EdgeStore[T]) GetByContext(ctx context.Context, db Executor, filters FilterSet) (*Edge[T], error) {
+	return s.GetByWithOptionsContext(ctx, db, filters, nil)
+}
+
+// GetByWithOptions returns a single typed edge by filters, the options
+// can include inactive edges
+func (s EdgeStore[T]) GetByWithOptions(db Executor, filters FilterSet, options *QueryOptions) (*Edge[T], error) {
+	return s.GetByWithOptionsContext(context.Background(), db, filters, options)
+}
+
+func (s EdgeStore[T]) GetByWithOptionsContext(ctx context.Context, db Executor, filters FilterSet, options *QueryOptions) (*Edge[T], error) {
+	edges, err := s.GetManyByWithOptionsContext(ctx, db, &filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if edges == nil || len(*edges) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &(*edges)[0], nil
+}
+
+// GetManyBy will return a typed EdgeSet and can be extended using a FilterSet.
+// Only active edges are returned, see GetManyByWithOptions to include inactive ones
+func (s EdgeStore[T]) GetManyBy(db Executor, filters *FilterSet) (*EdgeSet[T], error) {
+	return s.GetManyByContext(context.Background(), db, filters)
+}
+
+func (s EdgeStore[T]) GetManyByContext(ctx context.Context, db Executor, filters *FilterSet) (*EdgeSet[T], error) {
+	return s.GetManyByWithOptionsContext(ctx, db, filters, nil)
+}
+
+// GetManyByWithOptions will return a typed EdgeSet that can be extended using
+// a FilterSet and ordered, limited, or paged with QueryOptions. The edge
+// table is aliased as e, see EdgeProp
+func (s EdgeStore[T]) GetManyByWithOptions(db Executor, filters *FilterSet, options *QueryOptions) (*EdgeSet[T], error) {
+	return s.GetManyByWithOptionsContext(context.Background(), db, filters, options)
+}
+
+func (s EdgeStore[T]) GetManyByWithOptionsContext(ctx context.Context, db Executor, filters *FilterSet, options *QueryOptions) (*EdgeSet[T], error) {
+	query, params, err := s.graph.queryByOptions(s.graph.edgeTableName, "e", filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	edges, err := RowsToEdge[T](res)
+	if err != nil {
+		return nil, err
+	}
+
+	return edges, nil
+}
+
+// RelatedStore runs relationship queries against a Graph and returns typed
+// nodes and edges
+type RelatedStore[NodeType any, EdgeType any] struct {
+	graph *Graph
+}
+
+// Related returns a RelatedStore for nodes whose properties are NodeType
+// connected by edges whose properties are EdgeType
+func Related[NodeType any, EdgeType any](g *Graph) RelatedStore[NodeType, EdgeType] {
+	return RelatedStore[NodeType, EdgeType]{
+		graph: g,
+	}
+}
+
+// Out will do a single out hop from nodeID via the edgeType and return typed
+// nodes and edges. See Get
+func (s RelatedStore[NodeType, EdgeType]) Out(db Executor, nodeID, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return s.OutContext(context.Background(), db, nodeID, edgeType, filters)
+}
+
+func (s RelatedStore[NodeType, EdgeType]) OutContext(ctx context.Context, db Executor, nodeID, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return s.GetContext(ctx, db, nodeID, "out", edgeType, filters)
+}
+
+// In will do a single in hop from nodeID via the edgeType and return typed
+// nodes and edges. See Get
+func (s RelatedStore[NodeType, EdgeType]) In(db Executor, nodeID, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return s.InContext(context.Background(), db, nodeID, edgeType, filters)
+}
+
+func (s RelatedStore[NodeType, EdgeType]) InContext(ctx context.Context, db Executor, nodeID, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return s.GetContext(ctx, db, nodeID, "in", edgeType, filters)
+}
+
+// Get will do a single in or out hop from nodeID via the edgeType and return
+// typed nodes and edges. The edge table is aliased as e, and the node table
+// is aliased as n
+func (s RelatedStore[NodeType, EdgeType]) Get(db Executor, nodeID, direction, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return s.GetContext(context.Background(), db, nodeID, direction, edgeType, filters)
+}
+
+func (s RelatedStore[NodeType, EdgeType]) GetContext(ctx context.Context, db Executor, nodeID, direction, edgeType string, filters *FilterSet) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return s.GetWithOptionsContext(ctx, db, nodeID, direction, edgeType, filters, nil)
+}
+
+// GetWithOptions will do a single in or out hop from nodeID via the edgeType.
+// The result can be ordered, limited, or paged with QueryOptions whose
+// columns refer to the edge table
+func (s RelatedStore[NodeType, EdgeType]) GetWithOptions(db Executor, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return s.GetWithOptionsContext(context.Background(), db, nodeID, direction, edgeType, filters, options)
+}
+
+func (s RelatedStore[NodeType, EdgeType]) GetWithOptionsContext(ctx context.Context, db Executor, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	query, params, err := s.graph.relatedByQuery(nodeID, direction, edgeType, filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	set, err := RowsToNodeEdge[NodeType, EdgeType](rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
@@ -208,7 +208,8 @@ type GenericEdgeNode struct {
 	GenericNode
 }
 
-// GenericEdgeToType will convert a GenericEdge to the provided typed Edge
+// GenericEdgeToType will convert a GenericEdge to the provided typed Edge.
+// The id, type, active flag, and timestamps are carried over
 func GenericEdgeToType[T any](edgeInstance GenericEdge) (*Edge[T], error) {
 	by, err := json.Marshal(edgeInstance.Properties)
 	if err != nil {
@@ -220,31 +221,44 @@ func GenericEdgeToType[T any](edgeInstance GenericEdge) (*Edge[T], error) {
 		return nil, err
 	}
 
-	ne := NewEdge[T](edgeInstance.ID, edgeInstance.Type, edgeInstance.InID, edgeInstance.OutID, *ty)
+	ne := &Edge[T]{
+		entity:     edgeInstance.entity,
+		InID:       edgeInstance.InID,
+		OutID:      edgeInstance.OutID,
+		Properties: *ty,
+	}
 
 	return ne, nil
 }
 
-// GenericNodeToType will convert a GenericNode to the provided typed Node
+// GenericNodeToType will convert a GenericNode to the provided typed Node.
+// The id, type, active flag, and timestamps are carried over
 func GenericNodeToType[T any](nodeInstance GenericNode) (*Node[T], error) {
 	by, err := json.Marshal(nodeInstance.Properties)
 	if err != nil {
 		return nil, err
 	}
 
-	ty := new(T)
-	err = json.Unmarshal(by, ty)
+	ty, err := PropertiesToType[T](by)
 	if err != nil {
 		return nil, err
 	}
 
-	ne := NewNode[T](nodeInstance.ID, nodeInstance.Type, *ty)
+	ne := &Node[T]{
+		entity:     nodeInstance.entity,
+		Properties: *ty,
+	}
 
 	return ne, nil
 }
 
-// NewNode creates a typed Node
+// NewNode creates a typed Node. When nodeType is empty it is the type that T
+// is registered as in the DefaultRegistry
 func NewNode[T any](id, nodeType string, properties T) *Node[T] {
+	if nodeType == "" {
+		nodeType, _ = NodeTypeOf[T](DefaultRegistry)
+	}
+
 	return &Node[T]{
 		entity: entity{
 			ID:     id,
@@ -255,8 +269,13 @@ func NewNode[T any](id, nodeType string, properties T) *Node[T] {
 	}
 }
 
-// NewEdge creates a typed Edge
+// NewEdge creates a typed Edge. When edgeType is empty it is the type that T
+// is registered as in the DefaultRegistry
 func NewEdge[T any](id, edgeType, inID, outID string, properties T) *Edge[T] {
+	if edgeType == "" {
+		edgeType, _ = EdgeTypeOf[T](DefaultRegistry)
+	}
+
 	return &Edge[T]{
 		entity: entity{
 			ID:     id,
@@ -0,0 +1,126 @@
+package pyt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenericNodeToTypeRoundTrip(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	// a soft deleted node has every field that a new node would reset
+	_, err := g.NodeSoftDelete(db, "kram")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := &QueryOptions{IncludeInactive: true}
+	filters := &FilterSet{NewFilter("id", "kram")}
+
+	stored, err := Nodes[testUser](g).GetManyByWithOptions(db, filters, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generic, err := Nodes[GenericProperties](g).GetManyByWithOptions(db, filters, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*stored) != 1 || len(*generic) != 1 {
+		t.Fatalf("expected a single node, got %d and %d", len(*stored), len(*generic))
+	}
+
+	converted, err := GenericNodeToType[testUser](GenericNode(*generic.First()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if converted.Active {
+		t.Fatal("expected the converted node to stay inactive")
+	}
+
+	if !reflect.DeepEqual(*converted, *stored.First()) {
+		t.Fatalf("expected %+v, got %+v", *stored.First(), *converted)
+	}
+}
+
+func TestGenericEdgeToTypeRoundTrip(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	_, err := g.EdgeSoftDelete(db, "mark-jules")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := &QueryOptions{IncludeInactive: true}
+	filters := &FilterSet{NewFilter("id", "mark-jules")}
+
+	stored, err := Edges[testFollows](g).GetManyByWithOptions(db, filters, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generic, err := Edges[GenericProperties](g).GetManyByWithOptions(db, filters, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*stored) != 1 || len(*generic) != 1 {
+		t.Fatalf("expected a single edge, got %d and %d", len(*stored), len(*generic))
+	}
+
+	converted, err := GenericEdgeToType[testFollows](GenericEdge(*generic.First()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if converted.Active {
+		t.Fatal("expected the converted edge to stay inactive")
+	}
+
+	if !reflect.DeepEqual(*converted, *stored.First()) {
+		t.Fatalf("expected %+v, got %+v", *stored.First(), *converted)
+	}
+}
+
+func TestGenericEdgeNodeSetToTypesRoundTrip(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	set, err := g.NodesOutRelatedBy(db, "mark", "follows", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typed, err := GenericEdgeNodeSetToTypes[testUser, testFollows](*set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*typed) != len(*set) {
+		t.Fatalf("expected %d records, got %d", len(*set), len(*typed))
+	}
+
+	for _, record := range *typed {
+		node, err := Nodes[testUser](g).GetByID(db, record.Node.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(*record.Node, *node) {
+			t.Fatalf("expected %+v, got %+v", *node, *record.Node)
+		}
+
+		edge, err := Edges[testFollows](g).GetByID(db, record.Edge.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(*record.Edge, *edge) {
+			t.Fatalf("expected %+v, got %+v", *edge, *record.Edge)
+		}
+	}
+}
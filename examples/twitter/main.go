@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"text/tabwriter"
@@ -16,11 +17,23 @@ import (
 func init() {
 	// pyt.NodeTableName = "node_xxx_yyy"
 	// pyt.EdgeTableName = "anything_but_the_e_word"
+
+	// register the types so that BuildSchema creates the unique username
+	// index and edges can only connect the nodes that they are meant to
+	err := errors.Join(
+		pyt.RegisterNode[User](pyt.DefaultRegistry, "user"),
+		pyt.RegisterNode[Tweet](pyt.DefaultRegistry, "tweet"),
+		pyt.RegisterEdge[Follows](pyt.DefaultRegistry, "follows", pyt.Endpoint{In: "user", Out: "user"}),
+		pyt.RegisterEdge[Wrote](pyt.DefaultRegistry, "wrote", pyt.Endpoint{In: "user", Out: "tweet"}),
+	)
+	if err != nil {
+		p(`cannot register types`, err)
+	}
 }
 
 // nodes
 type User struct {
-	Username string `json:"username"`
+	Username string `json:"username" pyt:"unique"`
 	Loc      string `json:"loc"`
 }
 
@@ -63,23 +76,6 @@ func main() {
 		}
 	}
 
-	// let's make the username unique for user types
-	indexes := []string{
-		"DROP INDEX IF EXISTS user_username_idx",
-		`CREATE UNIQUE INDEX IF NOT EXISTS 
-			user_username_idx 
-		ON 
-			node(type, properties->'username') 
-		WHERE 
-			type = 'user'`,
-	}
-	for _, query := range indexes {
-		_, err = db.Exec(query)
-		if err != nil {
-			p(`unable to add unique user constraint`, err)
-		}
-	}
-
 	// add some users
 	mark := pyt.NewNode(uuid.NewString(), "user", User{
 		Username: "mark",
@@ -103,7 +99,7 @@ func main() {
 		Username: "mark",
 		Loc:      "some loc",
 	})
-	up, err := pyt.NodeUpsert(tx, "type, properties->'username'", "type='user'", *markup)
+	up, err := pyt.NodeUpsertByIndex(tx, "user_username_idx", *markup)
 	tx.Commit()
 	fmt.Println(up, err)
 	tx, _ = db.Begin()
@@ -0,0 +1,22 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executor runs queries against the database. It is satisfied by *sql.DB,
+// *sql.Tx, and *sql.Conn, reads can be run directly on the connection pool
+// while writes that need to be atomic are run in a transaction
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+var (
+	_ Executor = (*sql.DB)(nil)
+	_ Executor = (*sql.Tx)(nil)
+	_ Executor = (*sql.Conn)(nil)
+)
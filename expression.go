@@ -0,0 +1,114 @@
+package pyt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Expression is a node in a filter expression tree. A *filter is a leaf and
+// And, Or, and Not group other expressions. Every group is wrapped in
+// parentheses so the rendered sql does not depend on operator precedence
+//
+// ex:
+//
+// the users named mark or kram that are not in nyc
+//
+//	FilterSet{
+//		And(
+//			NewFilter("n.type", "user"),
+//			Or(NewFilter(NodeProp("username"), "mark"), NewFilter(NodeProp("username"), "kram")),
+//			Not(NewFilter(NodeProp("loc"), "nyc")),
+//		),
+//	}
+type Expression interface {
+	Build(params *[]any) string
+}
+
+type junction struct {
+	operator    string
+	expressions []Expression
+}
+
+// And matches when every expression matches
+func And(expressions ...Expression) Expression {
+	return junction{
+		operator:    "AND",
+		expressions: expressions,
+	}
+}
+
+// Or matches when any expression matches
+func Or(expressions ...Expression) Expression {
+	return junction{
+		operator:    "OR",
+		expressions: expressions,
+	}
+}
+
+// Build renders the expressions joined by the junction's operator. Nil and
+// empty expressions are skipped. With nothing left an And matches every row,
+// 1, and an Or matches none, 0
+func (j junction) Build(params *[]any) string {
+	clauses := []string{}
+
+	for _, e := range j.expressions {
+		if isNilExpression(e) {
+			continue
+		}
+
+		if clause := e.Build(params); clause != "" {
+			clauses = append(clauses, clause)
+		}
+	}
+
+	switch len(clauses) {
+	case 0:
+		if j.operator == "OR" {
+			return "0"
+		}
+
+		return "1"
+	case 1:
+		return clauses[0]
+	}
+
+	return fmt.Sprintf(`(%s)`, strings.Join(clauses, fmt.Sprintf(` %s `, j.operator)))
+}
+
+type not struct {
+	expression Expression
+}
+
+// Not matches when the expression does not match
+func Not(expression Expression) Expression {
+	return not{
+		expression: expression,
+	}
+}
+
+// Build renders the negated expression, an empty expression renders nothing
+func (n not) Build(params *[]any) string {
+	if isNilExpression(n.expression) {
+		return ""
+	}
+
+	clause := n.expression.Build(params)
+	if clause == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(`NOT (%s)`, clause)
+}
+
+// isNilExpression catches nil interfaces and typed nil pointers such as a
+// nil *filter
+func isNilExpression(e Expression) bool {
+	if e == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(e)
+
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
@@ -0,0 +1,47 @@
+package pyt
+
+import (
+	"testing"
+)
+
+func TestEmptyJunctions(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	var nilFilter *filter
+
+	tests := []struct {
+		name       string
+		expression Expression
+		clause     string
+		matches    int
+	}{
+		{name: "empty or", expression: Or(), clause: "0", matches: 0},
+		{name: "or of nil", expression: Or(nil), clause: "0", matches: 0},
+		{name: "or of a nil filter", expression: Or(nilFilter), clause: "0", matches: 0},
+		{name: "not of an empty or", expression: Not(Or()), clause: "NOT (0)", matches: 4},
+		{name: "empty and", expression: And(), clause: "1", matches: 4},
+		{name: "and of nil", expression: And(nil), clause: "1", matches: 4},
+		{name: "not of an empty and", expression: Not(And()), clause: "NOT (1)", matches: 0},
+		{name: "or of an empty and", expression: Or(And(), NewFilter("id", "mark")), clause: "(1 OR id=?)", matches: 4},
+		{name: "and of an empty or", expression: And(Or(), NewFilter("id", "mark")), clause: "(0 AND id=?)", matches: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := []any{}
+			if clause := test.expression.Build(&params); clause != test.clause {
+				t.Fatalf("expected %q, got %q", test.clause, clause)
+			}
+
+			nodes, err := Nodes[testUser](g).GetManyBy(db, &FilterSet{test.expression})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(*nodes) != test.matches {
+				t.Fatalf("expected %d nodes, got %d", test.matches, len(*nodes))
+			}
+		})
+	}
+}
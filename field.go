@@ -0,0 +1,347 @@
+package pyt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrBadField        error = errors.New("field is not a known column or property")
+	ErrBadFieldAlias   error = errors.New("field references an unknown table alias")
+	ErrBadOperator     error = errors.New("filter operator is not allowed")
+	ErrBadConnective   error = errors.New("sub filter comparison must be and or or")
+	ErrBadFilterValue  error = errors.New("filter value does not fit its operator")
+	ErrUnknownProperty error = errors.New("property is not registered")
+
+	// columnField is a column that is optionally prefixed with a table alias
+	columnField = regexp.MustCompile(`^(?:([A-Za-z_][A-Za-z0-9_]*)\.)?([A-Za-z_]+)$`)
+
+	// propertyField is a property reference as rendered by Prop, or written
+	// with the -> and ->> operators
+	propertyField = regexp.MustCompile(`^(?:json_extract\((?:([A-Za-z_][A-Za-z0-9_]*)\.)?properties, '([^']*)'\)|(?:([A-Za-z_][A-Za-z0-9_]*)\.)?properties->>?'([^']*)')$`)
+
+	// jsonPathKey is a single key of a json path, bare or double quoted
+	jsonPathKey = regexp.MustCompile(`^\.(?:([A-Za-z_][A-Za-z0-9_]*)|"([^"']+)")`)
+)
+
+// columns are the columns that the node and edge tables have in common and
+// the edge table's node ids
+var columns = map[string]bool{
+	"id":           true,
+	"active":       true,
+	"type":         true,
+	"properties":   true,
+	"time_created": true,
+	"time_updated": true,
+	"in_id":        true,
+	"out_id":       true,
+}
+
+// operators are the comparisons that a filter can use
+var operators = map[string]bool{
+	"=":           true,
+	"==":          true,
+	"!=":          true,
+	"<>":          true,
+	"<":           true,
+	"<=":          true,
+	">":           true,
+	">=":          true,
+	"IS":          true,
+	"IS NOT":      true,
+	"IN":          true,
+	"NOT IN":      true,
+	"BETWEEN":     true,
+	"NOT BETWEEN": true,
+	"IS NULL":     true,
+	"IS NOT NULL": true,
+	"LIKE":        true,
+	"NOT LIKE":    true,
+	"GLOB":        true,
+	"NOT GLOB":    true,
+	"CONTAINS":    true,
+}
+
+// fieldRules are the fields that a query's filters and ordering are allowed
+// to reference. Fields and comparisons are written into the query as is,
+// so anything that is not on the allow list is rejected
+type fieldRules struct {
+	aliases  map[string]bool
+	registry *Registry
+}
+
+func newFieldRules(registry *Registry, aliases ...string) fieldRules {
+	rules := fieldRules{
+		aliases:  map[string]bool{"": true},
+		registry: registry,
+	}
+
+	for _, alias := range aliases {
+		rules.aliases[alias] = true
+	}
+
+	return rules
+}
+
+// field returns an error unless the field is a known column or a property
+// that is registered, each of which may be prefixed with one of the aliases
+func (r fieldRules) field(field string) error {
+	if match := columnField.FindStringSubmatch(field); match != nil {
+		if !r.aliases[match[1]] {
+			return fmt.Errorf(`%w: %q`, ErrBadFieldAlias, field)
+		}
+
+		if !columns[match[2]] {
+			return fmt.Errorf(`%w: %q`, ErrBadField, field)
+		}
+
+		return nil
+	}
+
+	match := propertyField.FindStringSubmatch(field)
+	if match == nil {
+		return fmt.Errorf(`%w: %q`, ErrBadField, field)
+	}
+
+	alias, path := match[1], match[2]
+	if match[4] != "" || match[3] != "" {
+		alias, path = match[3], match[4]
+	}
+
+	if !r.aliases[alias] {
+		return fmt.Errorf(`%w: %q`, ErrBadFieldAlias, field)
+	}
+
+	// the -> operators also accept a bare key
+	if !strings.HasPrefix(path, "$") {
+		path = "$." + path
+	}
+
+	key, err := jsonPathFirstKey(path)
+	if err != nil {
+		return fmt.Errorf(`%w: %q`, ErrBadField, field)
+	}
+
+	return r.property(key)
+}
+
+// property returns an error when types are registered and none of them
+// have the property
+func (r fieldRules) property(key string) error {
+	if r.registry == nil || r.registry.hasProperty(key) {
+		return nil
+	}
+
+	return fmt.Errorf(`%w: %q`, ErrUnknownProperty, key)
+}
+
+// filters checks every field, operator, and connective in the expressions.
+// Expressions other than filters, And, Or, and Not are trusted
+func (r fieldRules) filters(expressions ...Expression) error {
+	for _, e := range expressions {
+		if isNilExpression(e) {
+			continue
+		}
+
+		var err error
+
+		switch e := e.(type) {
+		case *filter:
+			err = r.filter(*e)
+		case junction:
+			err = r.filters(e.expressions...)
+		case not:
+			err = r.filters(e.expression)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r fieldRules) filter(f filter) error {
+	err := r.field(f.Field)
+	if err != nil {
+		return err
+	}
+
+	operator := normalizeOperator(f.Comparision)
+	if !operators[operator] {
+		return fmt.Errorf(`%w: %q`, ErrBadOperator, f.Comparision)
+	}
+
+	if operator == "BETWEEN" || operator == "NOT BETWEEN" {
+		if values := filterValues(f.Value); len(values) != 2 {
+			return fmt.Errorf(`%w: %s on %q needs two values, got %d`, ErrBadFilterValue, operator, f.Field, len(values))
+		}
+	}
+
+	connective := strings.ToLower(strings.TrimSpace(f.SubFilterComparision))
+	if connective != "and" && connective != "or" && !(connective == "" && len(f.SubFilter) == 0) {
+		return fmt.Errorf(`%w: %q`, ErrBadConnective, f.SubFilterComparision)
+	}
+
+	return r.filters(f.SubFilter...)
+}
+
+// options checks the order by fields. They are prefixed with the query's
+// alias when they are built, so they must be bare columns
+func (r fieldRules) options(options *QueryOptions) error {
+	if options == nil {
+		return nil
+	}
+
+	for _, order := range options.OrderBy {
+		if order.Property != "" {
+			path, err := PropertyPath(order.Property)
+			if err != nil {
+				return err
+			}
+
+			key, err := jsonPathFirstKey(path)
+			if err != nil {
+				return err
+			}
+
+			err = r.property(key)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !columns[order.Field] {
+			return fmt.Errorf(`%w: %q`, ErrBadField, order.Field)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the filters against the allow list of columns, operators,
+// and the properties of the types in the registry, nil skips the property
+// check. Fields can be prefixed with any of the aliases. Every query runs
+// this check before it is built
+func (fs FilterSet) Validate(registry *Registry, aliases ...string) error {
+	return newFieldRules(registry, aliases...).filters(fs...)
+}
+
+// jsonPathFirstKey returns the first key of a json path, $.address.city
+// returns address. Every key of the path must be well formed
+func jsonPathFirstKey(path string) (string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return "", fmt.Errorf(`%w: %q`, ErrBadPropertyPath, path)
+	}
+
+	var first string
+	rest := path[1:]
+
+	for rest != "" {
+		match := jsonPathKey.FindStringSubmatch(rest)
+		if match == nil {
+			return "", fmt.Errorf(`%w: %q`, ErrBadPropertyPath, path)
+		}
+
+		if first == "" {
+			first = match[1] + match[2]
+		}
+
+		rest = rest[len(match[0]):]
+	}
+
+	if first == "" {
+		return "", fmt.Errorf(`%w: %q`, ErrBadPropertyPath, path)
+	}
+
+	return first, nil
+}
+
+// hasProperty reports if any registered type has the top level property.
+// When nothing is registered, or a registered type is not a struct, every
+// property is allowed
+func (r *Registry) hasProperty(key string) bool {
+	if r == nil {
+		return true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.nodes) == 0 && len(r.edges) == 0 {
+		return true
+	}
+
+	goTypes := []reflect.Type{}
+	for _, schema := range r.nodes {
+		goTypes = append(goTypes, schema.GoType)
+	}
+
+	for _, schema := range r.edges {
+		goTypes = append(goTypes, schema.GoType)
+	}
+
+	for _, goType := range goTypes {
+		for goType.Kind() == reflect.Pointer {
+			goType = goType.Elem()
+		}
+
+		if goType.Kind() != reflect.Struct {
+			return true
+		}
+
+		if jsonFields(goType)[key] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonFields returns the names that encoding/json uses for a struct's
+// fields, including the fields of embedded structs
+func jsonFields(goType reflect.Type) map[string]bool {
+	fields := map[string]bool{}
+
+	for i := 0; i < goType.NumField(); i++ {
+		field := goType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				for key := range jsonFields(embedded) {
+					fields[key] = true
+				}
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[name] = true
+	}
+
+	return fields
+}
@@ -2,38 +2,52 @@ package pyt
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
+	"unicode"
 )
 
-type FilterSet []*filter
+// FilterSet is a list of expressions that are combined into a where clause.
+// Siblings are combined from left to right using the connective of the
+// previous *filter, "and" for NewFilter and "or" for NewOrFilter, and AND
+// for any other Expression. Each pair is grouped so that
+//
+// FilterSet{NewOrFilter("a", 1), NewFilter("b", 2), NewFilter("c", 3)}
+//
+// renders as ((a=? OR b=?) AND c=?). Use And, Or, and Not to build any
+// other grouping
+type FilterSet []Expression
 
 // Build does the work of converting all of the filter instances
 // into a where clause. It will also append any values to the params slice
 // that is used in the final query
 func (fs FilterSet) Build(params *[]any) string {
-	if len(fs) == 0 {
-		return ""
-	}
+	var res string
+	var connective string
 
-	res := strings.Builder{}
-	res.WriteString("(")
+	for _, e := range fs {
+		if isNilExpression(e) {
+			continue
+		}
 
-	max := len(fs) - 1
-	for i, f := range fs {
-		if f == nil {
+		clause := e.Build(params)
+		if clause == "" {
 			continue
 		}
 
-		res.WriteString(f.Build(params))
+		if res == "" {
+			res = clause
+		} else {
+			res = fmt.Sprintf(`(%s %s %s)`, res, connective, clause)
+		}
 
-		if i != max {
-			res.WriteString(fmt.Sprintf(` %v `, f.SubFilterComparision))
+		connective = "AND"
+		if f, ok := e.(*filter); ok {
+			connective = f.connective()
 		}
 	}
 
-	res.WriteString(")")
-
-	return res.String()
+	return res
 }
 
 type filter struct {
@@ -48,15 +62,94 @@ type filter struct {
 // into a where clause. It will also append any values to the params slice
 // that is used in the final query
 func (f filter) Build(params *[]any) string {
-	*params = append(*params, f.Value)
+	clause := f.clause(params)
 	sub := f.SubFilter.Build(params)
-	if strings.TrimSpace(sub) != "" {
-		sub = fmt.Sprintf(`%s %s`, f.SubFilterComparision, sub)
+	if sub == "" {
+		return clause
+	}
+
+	return fmt.Sprintf(`(%s %s %s)`, clause, f.connective(), sub)
+}
+
+// connective is the SubFilterComparision as an AND or OR keyword
+func (f filter) connective() string {
+	if strings.EqualFold(strings.TrimSpace(f.SubFilterComparision), "or") {
+		return "OR"
+	}
+
+	return "AND"
+}
+
+// clause renders the filter's comparison and binds the number of params
+// that its operator needs. IN and NOT IN bind every value in a slice,
+// BETWEEN binds two, and IS NULL binds none
+func (f filter) clause(params *[]any) string {
+	operator := normalizeOperator(f.Comparision)
+
+	switch operator {
+	case "IN", "NOT IN":
+		values := filterValues(f.Value)
+
+		// an empty list matches nothing, or everything when it is negated
+		if len(values) == 0 {
+			if operator == "IN" {
+				return "0"
+			}
+
+			return "1"
+		}
+
+		holders := make([]string, len(values))
+		for i := range values {
+			holders[i] = "?"
+		}
+
+		*params = append(*params, values...)
+
+		return fmt.Sprintf(`%s %s (%s)`, f.Field, operator, strings.Join(holders, ", "))
+	case "BETWEEN", "NOT BETWEEN":
+		// the arity is checked by FilterSet.Validate before a query is built
+		*params = append(*params, filterValues(f.Value)...)
+
+		return fmt.Sprintf(`%s %s ? AND ?`, f.Field, operator)
+	case "IS NULL", "IS NOT NULL":
+		return fmt.Sprintf(`%s %s`, f.Field, operator)
+	case "CONTAINS":
+		*params = append(*params, f.Value)
+
+		return fmt.Sprintf(`EXISTS (SELECT 1 FROM json_each(%s) WHERE json_each.value = ?)`, f.Field)
+	}
+
+	comparison := f.Comparision
+	if operator != "" && unicode.IsLetter(rune(operator[0])) {
+		comparison = fmt.Sprintf(` %s `, operator)
+	}
+
+	*params = append(*params, f.Value)
+
+	return fmt.Sprintf(`%s%s?`, f.Field, comparison)
+}
+
+// normalizeOperator upper cases an operator and collapses its whitespace so
+// that "not  in" and "NOT IN" are the same operator everywhere
+func normalizeOperator(operator string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(operator)), " ")
+}
+
+// filterValues spreads a slice value into its elements, []byte is bound as
+// a single value
+func filterValues(value any) []any {
+	rv := reflect.ValueOf(value)
+	if value == nil || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{value}
 	}
 
-	fil := fmt.Sprintf(`%s%s? %s`, f.Field, f.Comparision, sub)
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
 
-	return fil
+	return values
 }
 
 // Add will register a subfilter on this filer
@@ -78,11 +171,124 @@ func NewOrFilter(field string, value any, subFilters ...*filter) *filter {
 
 // NewFilterFull builds a filter
 func NewFilterFull(field, comparison string, value any, subFilterComparision string, subFilters ...*filter) *filter {
+	sub := make(FilterSet, len(subFilters))
+	for i, f := range subFilters {
+		sub[i] = f
+	}
+
 	return &filter{
 		Field:                field,
 		Comparision:          comparison,
 		Value:                value,
 		SubFilterComparision: subFilterComparision,
-		SubFilter:            subFilters,
+		SubFilter:            sub,
 	}
 }
+
+// NewInFilter creates a filter that matches when the field is one of the
+// values. A single slice, NewInFilter("id", ids), is spread into its values.
+// An empty list of values matches nothing
+func NewInFilter(field string, values ...any) *filter {
+	return NewFilterFull(field, "IN", listValues(values), "and")
+}
+
+// NewNotInFilter creates a filter that matches when the field is none of
+// the values. An empty list of values matches everything
+func NewNotInFilter(field string, values ...any) *filter {
+	return NewFilterFull(field, "NOT IN", listValues(values), "and")
+}
+
+func listValues(values []any) []any {
+	if len(values) == 1 {
+		return filterValues(values[0])
+	}
+
+	return values
+}
+
+// NewBetweenFilter creates a filter that matches when the field is between
+// low and high, inclusive
+func NewBetweenFilter(field string, low, high any) *filter {
+	return NewFilterFull(field, "BETWEEN", []any{low, high}, "and")
+}
+
+// NewIsNullFilter creates a filter that matches when the field is null
+func NewIsNullFilter(field string) *filter {
+	return NewFilterFull(field, "IS NULL", nil, "and")
+}
+
+// NewIsNotNullFilter creates a filter that matches when the field is not null
+func NewIsNotNullFilter(field string) *filter {
+	return NewFilterFull(field, "IS NOT NULL", nil, "and")
+}
+
+// NewLikeFilter creates a filter that matches the field against a case
+// insensitive LIKE pattern, ex: "mar%"
+func NewLikeFilter(field, pattern string) *filter {
+	return NewFilterFull(field, "LIKE", pattern, "and")
+}
+
+// NewContainsFilter creates a filter that matches when the json array in
+// the field, usually a Prop, contains the value
+//
+// ex:
+//
+// NewContainsFilter(NodeProp("tags"), "golang")
+func NewContainsFilter(field string, value any) *filter {
+	return NewFilterFull(field, "CONTAINS", value, "and")
+}
+
+// NewGlobFilter creates a filter that matches the field against a case
+// sensitive GLOB pattern, ex: "mar*"
+func NewGlobFilter(field, pattern string) *filter {
+	return NewFilterFull(field, "GLOB", pattern, "and")
+}
+
+// Prop references a property in the properties json of the aliased table.
+// The property is a dot separated path, "address.city", and can be used as
+// the field of any filter
+//
+// ex:
+//
+// NewFilter(Prop("n2", "username"), "mark")
+//
+// Prop is meant for properties that are written in code and panics when
+// PropertyPath rejects the property. Use PropField for properties that come
+// from user input
+func Prop(alias, property string) string {
+	field, err := PropField(alias, property)
+	if err != nil {
+		panic(err)
+	}
+
+	return field
+}
+
+// PropField is Prop that returns the error from PropertyPath instead of
+// panicking
+func PropField(alias, property string) (string, error) {
+	if alias != "" {
+		alias = alias + "."
+	}
+
+	path, err := PropertyPath(property)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`json_extract(%sproperties, '%s')`, alias, path), nil
+}
+
+// NodeProp references a property of the node table. Node queries alias the
+// node table as n, including the node side of relationship queries. It
+// panics on a bad property, see Prop
+func NodeProp(property string) string {
+	return Prop("n", property)
+}
+
+// EdgeProp references a property of the edge table. Edge queries alias the
+// edge table as e, including the edge side of relationship queries. It
+// panics on a bad property, see Prop
+func EdgeProp(property string) string {
+	return Prop("e", property)
+}
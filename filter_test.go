@@ -0,0 +1,34 @@
+package pyt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPropRejectsBadPaths(t *testing.T) {
+	for _, property := range []string{"", "address.", "user'name", `user"name`} {
+		_, err := PropField("n", property)
+		if !errors.Is(err, ErrBadPropertyPath) {
+			t.Fatalf("%q expected ErrBadPropertyPath, got %v", property, err)
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%q expected Prop to panic", property)
+				}
+			}()
+
+			Prop("n", property)
+		}()
+	}
+
+	field, err := PropField("n", "address.city")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `json_extract(n.properties, '$.address.city')`; field != expected || Prop("n", "address.city") != expected {
+		t.Fatalf("expected %s, got %s", expected, field)
+	}
+}
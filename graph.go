@@ -0,0 +1,444 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Graph owns a database connection and the names of the node and edge tables
+// that make up a single graph. Multiple graphs, each with their own tables,
+// can live in the same process and the same database.
+//
+// Generic functions cannot be methods in Go, the typed node, edge, and
+// relationship functions are reached through Nodes, Edges, and Related
+//
+// ex:
+//
+// g := NewGraph(db, WithNodeTableName("person"), WithEdgeTableName("knows"))
+// err := g.BuildSchema()
+// users, err := Nodes[User](g).CreateMany(db, *mark, *kram)
+type Graph struct {
+	db            *sql.DB
+	nodeTableName string
+	edgeTableName string
+	edgePolicy    *edgePolicy
+	registry      *Registry
+}
+
+// GraphOption configures a Graph when it is created
+type GraphOption func(*Graph)
+
+// WithNodeTableName sets the name of the graph's node table
+func WithNodeTableName(nodeTableName string) GraphOption {
+	return func(g *Graph) {
+		g.nodeTableName = nodeTableName
+	}
+}
+
+// WithEdgeTableName sets the name of the graph's edge table
+func WithEdgeTableName(edgeTableName string) GraphOption {
+	return func(g *Graph) {
+		g.edgeTableName = edgeTableName
+	}
+}
+
+// WithRegistry sets the registry that the graph uses to infer types and
+// check edge endpoints. A nil registry turns off type inference, endpoint
+// checks, registered property indexes, and property checks in filters
+func WithRegistry(registry *Registry) GraphOption {
+	return func(g *Graph) {
+		g.registry = registry
+	}
+}
+
+// NewGraph creates a Graph for db. The table names default to
+// DefaultNodeTableName and DefaultEdgeTableName and edges follow the policy
+// stored with the schema. Without one, edges are unique by their type,
+// nodes, and properties with newer edges updating older ones
+func NewGraph(db *sql.DB, options ...GraphOption) *Graph {
+	g := &Graph{
+		db:            db,
+		nodeTableName: DefaultNodeTableName,
+		edgeTableName: DefaultEdgeTableName,
+		registry:      DefaultRegistry,
+	}
+
+	for _, option := range options {
+		option(g)
+	}
+
+	return g
+}
+
+// defaultGraph is the graph that the package level functions wrap. It is
+// created on every call so that changes to DefaultNodeTableName and
+// DefaultEdgeTableName are picked up
+func defaultGraph() *Graph {
+	return NewGraph(nil)
+}
+
+// tableGraph is the graph that the package level WithTableName functions wrap
+func tableGraph(nodeTableName, edgeTableName string) *Graph {
+	return NewGraph(nil, WithNodeTableName(nodeTableName), WithEdgeTableName(edgeTableName))
+}
+
+// DB returns the graph's database connection
+func (g *Graph) DB() *sql.DB {
+	return g.db
+}
+
+// NodeTableName returns the name of the graph's node table
+func (g *Graph) NodeTableName() string {
+	return g.nodeTableName
+}
+
+// EdgeTableName returns the name of the graph's edge table
+func (g *Graph) EdgeTableName() string {
+	return g.edgeTableName
+}
+
+// Begin starts a transaction on the graph's database connection
+func (g *Graph) Begin() (*sql.Tx, error) {
+	return g.db.Begin()
+}
+
+// WithTx runs fn in a transaction on the graph's database connection. See WithTx
+func (g *Graph) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return WithTx(ctx, g.db, fn)
+}
+
+// Registry returns the graph's registry
+func (g *Graph) Registry() *Registry {
+	return g.registry
+}
+
+// BuildSchema does the work of scaffoling the graph's tables and
+// should be called when the graph is created. It is safe to call on an
+// existing database, only the migrations that have not been applied are run
+func (g *Graph) BuildSchema() error {
+	return g.BuildSchemaContext(context.Background())
+}
+
+func (g *Graph) BuildSchemaContext(ctx context.Context) error {
+	err := g.migrate(ctx)
+	if err != nil {
+		return err
+	}
+
+	return g.WithTx(ctx, func(tx *sql.Tx) error {
+		err := g.applyEdgeUniqueness(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, index := range g.registry.PropertyIndexes() {
+			err := g.CreatePropertyIndexContext(ctx, tx, index)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// NodeDeleteByIDs will delete the nodes and, by way of the foreign keys,
+// every edge connected to them
+func (g *Graph) NodeDeleteByIDs(db Executor, nodeIDs ...string) (int64, error) {
+	return g.NodeDeleteByIDsContext(context.Background(), db, nodeIDs...)
+}
+
+func (g *Graph) NodeDeleteByIDsContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	holders, params := placeholders(nodeIDs)
+
+	query := fmt.Sprintf(`
+	DELETE FROM
+		%s
+	WHERE id IN (%s)
+	`, g.nodeTableName, holders)
+
+	res, err := db.ExecContext(ctx, query, params...)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// EdgeDeleteByIDs will delete the edges
+func (g *Graph) EdgeDeleteByIDs(db Executor, edgeIDs ...string) (int64, error) {
+	return g.EdgeDeleteByIDsContext(context.Background(), db, edgeIDs...)
+}
+
+func (g *Graph) EdgeDeleteByIDsContext(ctx context.Context, db Executor, edgeIDs ...string) (int64, error) {
+	holders, params := placeholders(edgeIDs)
+
+	query := fmt.Sprintf(`
+	DELETE FROM
+		%s
+	WHERE id IN (%s)
+	`, g.edgeTableName, holders)
+
+	res, err := db.ExecContext(ctx, query, params...)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// EdgeDeleteByNodeIDs will delete the edges whose in_id is in inIDs or
+// whose out_id is in outIDs
+func (g *Graph) EdgeDeleteByNodeIDs(db Executor, inIDs []string, outIDs []string) (int64, error) {
+	return g.EdgeDeleteByNodeIDsContext(context.Background(), db, inIDs, outIDs)
+}
+
+func (g *Graph) EdgeDeleteByNodeIDsContext(ctx context.Context, db Executor, inIDs []string, outIDs []string) (int64, error) {
+	params := []any{}
+	where := "WHERE "
+
+	if len(outIDs) > 0 {
+		outHolders, outParams := placeholders(outIDs)
+		params = append(params, outParams...)
+
+		where = fmt.Sprintf(`%s %s.out_id IN (%s)`, where, g.edgeTableName, outHolders)
+	}
+
+	if len(inIDs) > 0 {
+		inHolders, inParams := placeholders(inIDs)
+		params = append(params, inParams...)
+
+		if len(outIDs) > 0 {
+			where = where + " OR "
+		}
+
+		where = fmt.Sprintf(`%s %s.in_id IN (%s)`, where, g.edgeTableName, inHolders)
+	}
+
+	query := fmt.Sprintf(`
+	DELETE FROM
+		%s
+	%s
+	`, g.edgeTableName, where)
+
+	res, err := db.ExecContext(ctx, query, params...)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// NodesOutRelatedBy will do a single out hop from nodeID via the edgeType
+// can be extended with a FilterSet the edge table is aliased as e, and the
+// node table is aliased as n
+func (g *Graph) NodesOutRelatedBy(db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return g.NodesOutRelatedByContext(context.Background(), db, nodeID, edgeType, filters)
+}
+
+func (g *Graph) NodesOutRelatedByContext(ctx context.Context, db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return g.NodesGetRelatedByContext(ctx, db, nodeID, "out", edgeType, filters)
+}
+
+// NodesInRelatedBy will do a single in hop from nodeID via the edgeType
+// can be extended with a FilterSet the edge table is aliased as e, and the
+// node table is aliased as n
+func (g *Graph) NodesInRelatedBy(db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return g.NodesInRelatedByContext(context.Background(), db, nodeID, edgeType, filters)
+}
+
+func (g *Graph) NodesInRelatedByContext(ctx context.Context, db Executor, nodeID, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return g.NodesGetRelatedByContext(ctx, db, nodeID, "in", edgeType, filters)
+}
+
+// NodesGetRelatedBy will do a single in or out hop from nodeID via the edgeType
+// can be extended with a FilterSet the edge table is aliased as e, and the
+// node table is aliased as n. Only records where both the edge and node are
+// active are returned
+func (g *Graph) NodesGetRelatedBy(db Executor, nodeID, direction, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return g.NodesGetRelatedByContext(context.Background(), db, nodeID, direction, edgeType, filters)
+}
+
+func (g *Graph) NodesGetRelatedByContext(ctx context.Context, db Executor, nodeID, direction, edgeType string, filters *FilterSet) (*GenericEdgeNodeSet, error) {
+	return g.NodesGetRelatedByWithOptionsContext(ctx, db, nodeID, direction, edgeType, filters, nil)
+}
+
+// NodesGetRelatedByWithOptions will do a single in or out hop from nodeID via
+// the edgeType. The result can be ordered, limited, or paged with QueryOptions
+// whose columns refer to the edge table
+func (g *Graph) NodesGetRelatedByWithOptions(db Executor, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*GenericEdgeNodeSet, error) {
+	return g.NodesGetRelatedByWithOptionsContext(context.Background(), db, nodeID, direction, edgeType, filters, options)
+}
+
+func (g *Graph) NodesGetRelatedByWithOptionsContext(ctx context.Context, db Executor, nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (*GenericEdgeNodeSet, error) {
+	query, params, err := g.relatedByQuery(nodeID, direction, edgeType, filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	resp, err := RowsToGenericEdgeNode(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// relatedByQuery builds the query for a single in or out hop from nodeID
+func (g *Graph) relatedByQuery(nodeID, direction, edgeType string, filters *FilterSet, options *QueryOptions) (string, []any, error) {
+	edgeWhere := "in_id"
+	edgeJoin := "out_id"
+
+	if direction == "in" {
+		edgeJoin = "in_id"
+		edgeWhere = "out_id"
+	}
+
+	params := []any{nodeID, edgeType}
+	clauses := []string{}
+
+	err := g.validateQuery(filters, options, "e", "n")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if filters != nil {
+		filterClauses := filters.Build(&params)
+		if filterClauses != "" {
+			clauses = append(clauses, filterClauses)
+		}
+	}
+
+	keyset, tail, err := options.Build("e", &params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if keyset != "" {
+		clauses = append(clauses, keyset)
+	}
+
+	if active := options.activeClause("e", "n"); active != "" {
+		clauses = append(clauses, active)
+	}
+
+	var where string
+	for _, clause := range clauses {
+		where = fmt.Sprintf(`%sAND
+		%s
+	`, where, clause)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s e
+	JOIN
+		%s n ON n.id = e.%s
+	WHERE
+		e.%s = ?
+	AND
+		e.type = ?
+	%s
+	%s
+	`, edgeNodeColumns("e", "n"), g.edgeTableName, g.nodeTableName, edgeJoin, edgeWhere, where, tail)
+
+	return query, params, nil
+}
+
+// queryByOptions builds the select for a single table that is extended with
+// a FilterSet and QueryOptions. The table is aliased so that filters can
+// reference it the same way that they do in relationship queries
+func (g *Graph) queryByOptions(tableName, alias string, filters *FilterSet, options *QueryOptions) (string, []any, error) {
+	params := []any{}
+	clauses := []string{}
+	var where string
+
+	err := g.validateQuery(filters, options, alias)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if filters != nil {
+		filterClauses := filters.Build(&params)
+		if filterClauses != "" {
+			clauses = append(clauses, filterClauses)
+		}
+	}
+
+	keyset, tail, err := options.Build(alias, &params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if keyset != "" {
+		clauses = append(clauses, keyset)
+	}
+
+	if active := options.activeClause(alias); active != "" {
+		clauses = append(clauses, active)
+	}
+
+	if len(clauses) > 0 {
+		where = fmt.Sprintf(`WHERE
+		%s`, strings.Join(clauses, "\n\tAND\n\t\t"))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s.*
+	FROM
+		%s %s
+	%s
+	%s
+	`, alias, tableName, alias, where, tail)
+
+	return query, params, nil
+}
+
+// validateQuery checks the filters and ordering of a query against the
+// columns, the aliases that the query uses, and the graph's registry
+func (g *Graph) validateQuery(filters *FilterSet, options *QueryOptions, aliases ...string) error {
+	rules := newFieldRules(g.registry, aliases...)
+
+	if filters != nil {
+		err := rules.filters(*filters...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rules.options(options)
+}
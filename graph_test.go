@@ -0,0 +1,193 @@
+package pyt
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type testUser struct {
+	Username string `json:"username"`
+	Age      int    `json:"age"`
+}
+
+type testFollows struct {
+	Since int `json:"since"`
+}
+
+// newTestGraph builds the schema in a new in memory database. A single
+// connection is kept open because every connection to :memory: gets its own
+// database
+func newTestGraph(t *testing.T) (*Graph, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	g := NewGraph(db, WithRegistry(NewRegistry()))
+
+	err = g.BuildSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return g, db
+}
+
+// seedFollows creates four users where mark follows kram, jules, and ana,
+// and kram and jules follow ana
+func seedFollows(t *testing.T, g *Graph, db Executor) {
+	t.Helper()
+
+	_, err := Nodes[testUser](g).CreateMany(db,
+		*NewNode("mark", "user", testUser{"mark", 40}),
+		*NewNode("kram", "user", testUser{"kram", 25}),
+		*NewNode("jules", "user", testUser{"jules", 33}),
+		*NewNode("ana", "user", testUser{"ana", 51}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Edges[testFollows](g).CreateMany(db,
+		*NewEdge("mark-kram", "follows", "mark", "kram", testFollows{2019}),
+		*NewEdge("mark-jules", "follows", "mark", "jules", testFollows{2021}),
+		*NewEdge("mark-ana", "follows", "mark", "ana", testFollows{2023}),
+		*NewEdge("kram-ana", "follows", "kram", "ana", testFollows{2020}),
+		*NewEdge("jules-ana", "follows", "jules", "ana", testFollows{2022}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func relatedNodeIDs(set *GenericEdgeNodeSet) []string {
+	ids := []string{}
+	for _, related := range *set {
+		ids = append(ids, related.GenericNode.ID)
+	}
+
+	slices.Sort(ids)
+
+	return ids
+}
+
+func TestRelatedByFilters(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	tests := []struct {
+		name      string
+		direction string
+		nodeID    string
+		filters   *FilterSet
+		expected  []string
+	}{
+		{
+			name:      "out without filters",
+			direction: "out",
+			nodeID:    "mark",
+			expected:  []string{"ana", "jules", "kram"},
+		},
+		{
+			name:      "out filtered by a node property",
+			direction: "out",
+			nodeID:    "mark",
+			filters:   &FilterSet{NewFilterFull(NodeProp("age"), ">", 30, "and")},
+			expected:  []string{"ana", "jules"},
+		},
+		{
+			name:      "out filtered by an edge property",
+			direction: "out",
+			nodeID:    "mark",
+			filters:   &FilterSet{NewFilterFull(EdgeProp("since"), "<", 2022, "and")},
+			expected:  []string{"jules", "kram"},
+		},
+		{
+			name:      "out filtered by a sub filter group",
+			direction: "out",
+			nodeID:    "mark",
+			filters: &FilterSet{
+				NewFilterFull(NodeProp("age"), ">", 30, "and",
+					NewOrFilter("n.id", "ana", NewFilter("n.id", "kram")),
+				),
+			},
+			expected: []string{"ana"},
+		},
+		{
+			name:      "in without filters",
+			direction: "in",
+			nodeID:    "ana",
+			expected:  []string{"jules", "kram", "mark"},
+		},
+		{
+			name:      "in filtered by a node property",
+			direction: "in",
+			nodeID:    "ana",
+			filters:   &FilterSet{NewFilterFull(NodeProp("age"), "<", 35, "and")},
+			expected:  []string{"jules", "kram"},
+		},
+		{
+			name:      "in filtered by an expression",
+			direction: "in",
+			nodeID:    "ana",
+			filters: &FilterSet{
+				Or(
+					NewFilter("n.id", "mark"),
+					NewFilterFull(EdgeProp("since"), ">=", 2022, "and"),
+				),
+			},
+			expected: []string{"jules", "mark"},
+		},
+		{
+			name:      "in filtered to nothing",
+			direction: "in",
+			nodeID:    "ana",
+			filters:   &FilterSet{NewFilter(NodeProp("username"), "nobody")},
+			expected:  []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set, err := g.NodesGetRelatedBy(db, test.nodeID, test.direction, "follows", test.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ids := relatedNodeIDs(set); !slices.Equal(ids, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, ids)
+			}
+
+			set, err = NodesGetRelatedByWithTableName(db, g.NodeTableName(), g.EdgeTableName(), test.nodeID, test.direction, "follows", test.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ids := relatedNodeIDs(set); !slices.Equal(ids, test.expected) {
+				t.Fatalf("expected %v from the package function, got %v", test.expected, ids)
+			}
+		})
+	}
+}
+
+func TestRelatedByFiltersAreValidated(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	filters := &FilterSet{NewFilter("n.id; DROP TABLE node", "mark")}
+
+	_, err := g.NodesOutRelatedBy(db, "mark", "follows", filters)
+	if err == nil {
+		t.Fatal("expected the filter field to be rejected")
+	}
+}
@@ -0,0 +1,170 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// legacyIndexNames were shared by the node and edge tables in the first
+// version of the schema. Index names are global in sqlite, so only the
+// table that was created first got them
+var legacyIndexNames = []string{
+	"id_idx",
+	"type_idx",
+	"time_created_idx",
+	"time_updated_idx",
+	"in_id_idx",
+	"out_id_idx",
+}
+
+type index struct {
+	name    string
+	table   string
+	columns string
+}
+
+// indexes are the indexes that a graph's schema is expected to have. Their
+// names are prefixed with the table name so that the node and edge tables,
+// and the tables of multiple graphs, do not collide
+func (g *Graph) indexes() []index {
+	return []index{
+		{name: g.nodeTableName + "_id_idx", table: g.nodeTableName, columns: "id"},
+		{name: g.nodeTableName + "_type_idx", table: g.nodeTableName, columns: "type"},
+		{name: g.nodeTableName + "_time_created_idx", table: g.nodeTableName, columns: "time_created"},
+		{name: g.nodeTableName + "_time_updated_idx", table: g.nodeTableName, columns: "time_updated"},
+		{name: g.edgeTableName + "_in_id_idx", table: g.edgeTableName, columns: "in_id"},
+		{name: g.edgeTableName + "_out_id_idx", table: g.edgeTableName, columns: "out_id"},
+		{name: g.edgeTableName + "_type_idx", table: g.edgeTableName, columns: "type"},
+		{name: g.edgeTableName + "_time_created_idx", table: g.edgeTableName, columns: "time_created"},
+		{name: g.edgeTableName + "_time_updated_idx", table: g.edgeTableName, columns: "time_updated"},
+		{name: softDeleteTableName(g.edgeTableName) + "_edge_id_idx", table: softDeleteTableName(g.edgeTableName), columns: "edge_id"},
+	}
+}
+
+// createIndexes creates any of the graph's indexes that are missing
+func (g *Graph) createIndexes(ctx context.Context, tx *sql.Tx) error {
+	for _, idx := range g.indexes() {
+		query := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s(%s);`, idx.name, idx.table, idx.columns)
+
+		_, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tableIndexes is the migration that replaces the legacy index names with
+// ones that are prefixed by their table. A legacy index is only dropped when
+// it belongs to one of the graph's tables
+func tableIndexes(ctx context.Context, tx *sql.Tx, g *Graph) error {
+	err := g.createIndexes(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	holders, params := placeholders(legacyIndexNames)
+	query := fmt.Sprintf(`
+	SELECT
+		name
+	FROM
+		sqlite_master
+	WHERE
+		type = 'index'
+	AND
+		tbl_name IN (?, ?)
+	AND
+		name IN (%s)
+	`, holders)
+
+	rows, err := tx.QueryContext(ctx, query, append([]any{g.nodeTableName, g.edgeTableName}, params...)...)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		names = append(names, name)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP INDEX IF EXISTS %s;`, name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VerifyIndexes returns the names of the indexes that the graph's schema
+// expects but are missing from the database. An empty result means that
+// every index is in place
+func (g *Graph) VerifyIndexes(db Executor) ([]string, error) {
+	return g.VerifyIndexesContext(context.Background(), db)
+}
+
+func (g *Graph) VerifyIndexesContext(ctx context.Context, db Executor) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+	SELECT
+		name,
+		tbl_name
+	FROM
+		sqlite_master
+	WHERE
+		type = 'index'
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	existing := map[string]string{}
+	for rows.Next() {
+		var name, table string
+		err := rows.Scan(&name, &table)
+		if err != nil {
+			return nil, err
+		}
+
+		existing[name] = table
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	policy, err := g.resolveEdgePolicy(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	unique, err := g.edgeUniqueIndex(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := []string{}
+	for _, idx := range append(g.indexes(), unique...) {
+		if table, ok := existing[idx.name]; !ok || table != idx.table {
+			missing = append(missing, idx.name)
+		}
+	}
+
+	return missing, nil
+}
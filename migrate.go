@@ -0,0 +1,244 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrationTableName records which migrations have been applied to each
+// graph. Multiple graphs can share a database so the version is kept per
+// node and edge table pair instead of in PRAGMA user_version
+const migrationTableName string = "pyt_migration"
+
+// migration is a single step in the evolution of a graph's schema. It is run
+// in a single transaction along with recording its version. Every step
+// should be safe to run against a database that already has the change,
+// databases built before migrations existed are at version 0
+type migration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, tx *sql.Tx, g *Graph) error
+}
+
+// migrations must be ordered by version and never changed once released,
+// new schema changes are added to the end of the list
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create the node, edge, and soft delete tables",
+		up:          execQueries(schemaV1),
+	},
+	{
+		version:     2,
+		description: "name indexes after their table",
+		up:          tableIndexes,
+	},
+	{
+		version:     3,
+		description: "include the edge type in edge uniqueness",
+		up:          edgeTableWithoutUnique,
+	},
+	{
+		version:     4,
+		description: "store property index definitions",
+		up:          execQueries(propertyIndexTable),
+	},
+	{
+		version:     5,
+		description: "store the edge uniqueness policy",
+		up:          edgePolicyTable,
+	},
+}
+
+// LatestSchemaVersion is the version that BuildSchema migrates a graph to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// execQueries creates a migration step that runs each of the queries
+func execQueries(queries func(g *Graph) []string) func(ctx context.Context, tx *sql.Tx, g *Graph) error {
+	return func(ctx context.Context, tx *sql.Tx, g *Graph) error {
+		for _, query := range queries(g) {
+			_, err := tx.ExecContext(ctx, query)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func schemaV1(g *Graph) []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+			id TEXT NOT NULL UNIQUE PRIMARY KEY,
+			active INTEGER DEFAULT 1,
+			type TEXT NOT NULL,
+			properties TEXT,
+			time_created TEXT NOT NULL DEFAULT (strftime(%[2]s)),
+			time_updated TEXT NOT NULL DEFAULT (strftime(%[2]s))
+		) strict;`, g.nodeTableName, timeFormat),
+
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_time_updated_trigger
+		AFTER UPDATE ON %[1]s
+		BEGIN
+			UPDATE
+				 %[1]s
+			SET 
+				time_updated = STRFTIME(%[2]s, 'NOW')
+			WHERE id = NEW.id;
+		END;`, g.nodeTableName, timeFormat),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS id_idx ON %s(id);`, g.nodeTableName),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS type_idx ON %s(type);`, g.nodeTableName),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS time_created_idx ON %s(time_created);`, g.nodeTableName),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS time_updated_idx ON %s(time_updated);`, g.nodeTableName),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+			id TEXT NOT NULL UNIQUE PRIMARY KEY,
+			active INTEGER DEFAULT 1,
+			type TEXT NOT NULL,
+			in_id TEXT,
+			out_id TEXT,
+			properties TEXT,
+			time_created TEXT NOT NULL DEFAULT (strftime(%[3]s)),
+			time_updated TEXT NOT NULL DEFAULT (strftime(%[3]s)),
+			UNIQUE(in_id, out_id, properties) ON CONFLICT REPLACE,
+			FOREIGN KEY(in_id) REFERENCES %[2]s(id) ON DELETE CASCADE,
+			FOREIGN KEY(out_id) REFERENCES %[2]s(id) ON DELETE CASCADE
+		) strict;`, g.edgeTableName, g.nodeTableName, timeFormat),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS in_id_idx ON %s(in_id);`, g.edgeTableName),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS out_id_idx ON %s(out_id);`, g.edgeTableName),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS type_idx ON %s(type);`, g.edgeTableName),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS time_created_idx ON %s(time_created);`, g.edgeTableName),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS time_updated_idx ON %s(time_updated);`, g.edgeTableName),
+
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_time_updated_trigger
+		AFTER UPDATE ON %[1]s
+		BEGIN
+			UPDATE
+				%[1]s 
+			SET 
+				time_updated = STRFTIME(%[2]s, 'NOW')
+			WHERE id = NEW.id;
+		END;`, g.edgeTableName, timeFormat),
+
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			node_id TEXT NOT NULL,
+			edge_id TEXT NOT NULL,
+			PRIMARY KEY(node_id, edge_id)
+		) strict;`, softDeleteTableName(g.edgeTableName)),
+
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_edge_id_idx ON %[1]s(edge_id);`, softDeleteTableName(g.edgeTableName)),
+	}
+}
+
+// SchemaVersion returns the version of the graph's schema. Zero means that
+// no migrations have been applied by BuildSchema
+func (g *Graph) SchemaVersion(db Executor) (int, error) {
+	return g.SchemaVersionContext(context.Background(), db)
+}
+
+func (g *Graph) SchemaVersionContext(ctx context.Context, db Executor) (int, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, `
+	SELECT
+		COUNT(*) > 0
+	FROM
+		sqlite_master
+	WHERE
+		type = 'table'
+	AND
+		name = ?
+	`, migrationTableName).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(MAX(version), 0)
+	FROM
+		%s
+	WHERE
+		node_table = ?
+	AND
+		edge_table = ?
+	`, migrationTableName)
+
+	var version int
+	err = db.QueryRowContext(ctx, query, g.nodeTableName, g.edgeTableName).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// migrate applies every migration newer than the graph's schema version.
+// Each migration is run in its own transaction so a failure leaves the
+// graph at the last version that succeeded
+func (g *Graph) migrate(ctx context.Context) error {
+	table := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		node_table TEXT NOT NULL,
+		edge_table TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		description TEXT NOT NULL,
+		time_applied TEXT NOT NULL DEFAULT (strftime(%s)),
+		PRIMARY KEY(node_table, edge_table, version)
+	) strict;`, migrationTableName, timeFormat)
+
+	for _, step := range migrations {
+		err := g.WithTx(ctx, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, table)
+			if err != nil {
+				return err
+			}
+
+			version, err := g.SchemaVersionContext(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			if version >= step.version {
+				return nil
+			}
+
+			err = step.up(ctx, tx, g)
+			if err != nil {
+				return fmt.Errorf("migration %d %s: %w", step.version, step.description, err)
+			}
+
+			query := fmt.Sprintf(`
+			INSERT INTO
+				%s
+				(node_table, edge_table, version, description)
+			VALUES
+				(?, ?, ?, ?)
+			`, migrationTableName)
+
+			_, err = tx.ExecContext(ctx, query, g.nodeTableName, g.edgeTableName, step.version, step.description)
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// newBaselineGraph creates the schema that BuildSchema made before there were
+// migrations, with the edge table's UNIQUE(in_id, out_id, properties)
+// constraint, and a user that follows another
+func newBaselineGraph(t *testing.T) (*Graph, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	g := NewGraph(db, WithRegistry(NewRegistry()))
+
+	for _, query := range schemaV1(g) {
+		_, err := db.Exec(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries := []string{
+		`INSERT INTO node (id, type, properties) VALUES ('mark', 'user', '{}'), ('kram', 'user', '{}')`,
+		`INSERT INTO edge (id, type, in_id, out_id, properties) VALUES ('mark-kram', 'follows', 'mark', 'kram', '{}')`,
+	}
+
+	for _, query := range queries {
+		_, err := db.Exec(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return g, db
+}
+
+func TestMigrateBaselineKeepsEdgesUnique(t *testing.T) {
+	g, db := newBaselineGraph(t)
+
+	err := BuildSchema(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := g.SchemaVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != LatestSchemaVersion() {
+		t.Fatalf("expected version %d, got %d", LatestSchemaVersion(), version)
+	}
+
+	policy, err := g.storedEdgePolicy(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if policy == nil || *policy != defaultEdgePolicy {
+		t.Fatalf("expected the stored policy to be %+v, got %+v", defaultEdgePolicy, policy)
+	}
+
+	_, err = EdgeCreate(db, *NewEdge("mark-kram-again", "follows", "mark", "kram", GenericProperties{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edges, err := EdgesGetBy[GenericProperties](db, &FilterSet{NewFilter("type", "follows")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*edges) != 1 {
+		t.Fatalf("expected the duplicate edge to be deduplicated, got %d edges", len(*edges))
+	}
+}
+
+func TestMigrateBaselineWithEdgeMulti(t *testing.T) {
+	_, db := newBaselineGraph(t)
+
+	g := NewGraph(db, WithRegistry(NewRegistry()), WithEdgeUniqueness(EdgeMulti, EdgeConflictError))
+
+	err := g.BuildSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = EdgeCreate(db, *NewEdge("mark-kram-again", "follows", "mark", "kram", GenericProperties{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edges, err := EdgesGetBy[GenericProperties](db, &FilterSet{NewFilter("type", "follows")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*edges) != 2 {
+		t.Fatalf("expected the stored EdgeMulti policy to allow both edges, got %d edges", len(*edges))
+	}
+}
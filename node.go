@@ -0,0 +1,371 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// NodeStore reads and writes typed nodes in a Graph's node table
+type NodeStore[T any] struct {
+	graph *Graph
+}
+
+// Nodes returns a NodeStore for nodes whose properties are T
+func Nodes[T any](g *Graph) NodeStore[T] {
+	return NodeStore[T]{
+		graph: g,
+	}
+}
+
+// nodeType returns the node's type or the type that T is registered as in
+// the graph's registry when the node does not have one
+func (s NodeStore[T]) nodeType(node Node[T]) string {
+	if node.Type != "" {
+		return node.Type
+	}
+
+	name, _ := NodeTypeOf[T](s.graph.registry)
+
+	return name
+}
+
+// Create will add a node to the database
+func (s NodeStore[T]) Create(db Executor, newNode Node[T]) (*Node[T], error) {
+	return s.CreateContext(context.Background(), db, newNode)
+}
+
+func (s NodeStore[T]) CreateContext(ctx context.Context, db Executor, newNode Node[T]) (*Node[T], error) {
+	nodes, err := s.CreateManyContext(ctx, db, newNode)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodes == nil || len(*nodes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &(*nodes)[0], nil
+}
+
+// CreateMany will add mulitple nodes to the database
+func (s NodeStore[T]) CreateMany(db Executor, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return s.CreateManyContext(context.Background(), db, newNodes...)
+}
+
+func (s NodeStore[T]) CreateManyContext(ctx context.Context, db Executor, newNodes ...Node[T]) (*NodeSet[T], error) {
+	err := s.validate(newNodes...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(newNodes))
+	params := []any{}
+
+	for i := 0; i < len(newNodes); i++ {
+		values[i] = "(?, ?, ?, ?)"
+		properties, err := json.Marshal(newNodes[i].Properties)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, newNodes[i].entity.ID, newNodes[i].entity.Active, s.nodeType(newNodes[i]), string(properties))
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO
+		%s
+		(id, active, type, properties)
+	VALUES
+		%s
+	RETURNING
+		*
+	`, s.graph.nodeTableName, strings.Join(values, ","))
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	nodes, err := RowsToNode[T](res)
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// Upsert will execute an upsert query based on the conflictColumns and the
+// conflictCluase values. See NodeUpsert
+func (s NodeStore[T]) Upsert(db Executor, conflictColumns, conflictClause string, newNode Node[T]) (*Node[T], error) {
+	return s.UpsertContext(context.Background(), db, conflictColumns, conflictClause, newNode)
+}
+
+func (s NodeStore[T]) UpsertContext(ctx context.Context, db Executor, conflictColumns, conflictClause string, newNode Node[T]) (*Node[T], error) {
+	nodes, err := s.UpsertManyContext(ctx, db, conflictColumns, conflictClause, newNode)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodes == nil || len(*nodes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return nodes.First(), nil
+}
+
+// UpsertMany will execute an upsert query based on the conflictColumns and the
+// conflictCluase values. See NodesUpsert
+func (s NodeStore[T]) UpsertMany(db Executor, conflictColumns, conflictClause string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return s.UpsertManyContext(context.Background(), db, conflictColumns, conflictClause, newNodes...)
+}
+
+func (s NodeStore[T]) UpsertManyContext(ctx context.Context, db Executor, conflictColumns, conflictClause string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	if len(conflictColumns) == 0 {
+		return nil, ErrBadUpsertQuery
+	}
+
+	err := s.validate(newNodes...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(newNodes))
+	params := []any{}
+
+	for i := 0; i < len(newNodes); i++ {
+		values[i] = "(?, ?, ?, ?)"
+		properties, err := json.Marshal(newNodes[i].Properties)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, newNodes[i].entity.ID, newNodes[i].entity.Active, s.nodeType(newNodes[i]), string(properties))
+	}
+
+	if strings.TrimSpace(conflictClause) != "" {
+		conflictClause = "WHERE " + conflictClause
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO
+		%s
+		(id, active, type, properties)
+	VALUES
+		%s
+	ON CONFLICT (%s) %s DO UPDATE SET
+		active = excluded.active,
+		properties = excluded.properties
+	RETURNING
+		*
+	`, s.graph.nodeTableName, strings.Join(values, ","), conflictColumns, conflictClause)
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	nodes, err := RowsToNode[T](res)
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// UpsertByIndex will upsert the node using the conflict target of a unique
+// PropertyIndex. See CreatePropertyIndex
+func (s NodeStore[T]) UpsertByIndex(db Executor, indexName string, newNode Node[T]) (*Node[T], error) {
+	return s.UpsertByIndexContext(context.Background(), db, indexName, newNode)
+}
+
+func (s NodeStore[T]) UpsertByIndexContext(ctx context.Context, db Executor, indexName string, newNode Node[T]) (*Node[T], error) {
+	nodes, err := s.UpsertManyByIndexContext(ctx, db, indexName, newNode)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodes == nil || len(*nodes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return nodes.First(), nil
+}
+
+// UpsertManyByIndex will upsert the nodes using the conflict target of a
+// unique PropertyIndex
+func (s NodeStore[T]) UpsertManyByIndex(db Executor, indexName string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	return s.UpsertManyByIndexContext(context.Background(), db, indexName, newNodes...)
+}
+
+func (s NodeStore[T]) UpsertManyByIndexContext(ctx context.Context, db Executor, indexName string, newNodes ...Node[T]) (*NodeSet[T], error) {
+	conflictColumns, conflictClause, err := s.graph.upsertTarget(ctx, db, indexName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UpsertManyContext(ctx, db, conflictColumns, conflictClause, newNodes...)
+}
+
+// Update updates a node's properties. updatedNode.ID must exist in the database
+func (s NodeStore[T]) Update(db Executor, updatedNode Node[T], withReturn bool) (*Node[T], error) {
+	return s.UpdateContext(context.Background(), db, updatedNode, withReturn)
+}
+
+func (s NodeStore[T]) UpdateContext(ctx context.Context, db Executor, updatedNode Node[T], withReturn bool) (*Node[T], error) {
+	err := s.validate(updatedNode)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		active = ?,
+		properties = ?
+	WHERE
+		id = ?
+	RETURNING
+		*
+	`, s.graph.nodeTableName)
+	properties, err := json.Marshal(updatedNode.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.ExecContext(ctx, query, updatedNode.entity.Active, string(properties), updatedNode.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !withReturn {
+		return nil, nil
+	}
+
+	// the node is loaded even if the update soft deleted it
+	nodes, err := s.GetManyByWithOptionsContext(ctx, db, &FilterSet{NewFilter("id", updatedNode.ID)}, &QueryOptions{IncludeInactive: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(*nodes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return nodes.First(), nil
+}
+
+// GetByID retrieves and typed node by its id. Only an active node is
+// returned, see GetByIDWithOptions to include an inactive one
+func (s NodeStore[T]) GetByID(db Executor, id string) (*Node[T], error) {
+	return s.GetByIDContext(context.Background(), db, id)
+}
+
+func (s NodeStore[T]) GetByIDContext(ctx context.Context, db Executor, id string) (*Node[T], error) {
+	return s.GetByIDWithOptionsContext(ctx, db, id, nil)
+}
+
+// GetByIDWithOptions retrieves a typed node by its id, the options can
+// include an inactive node
+func (s NodeStore[T]) GetByIDWithOptions(db Executor, id string, options *QueryOptions) (*Node[T], error) {
+	return s.GetByIDWithOptionsContext(context.Background(), db, id, options)
+}
+
+func (s NodeStore[T]) GetByIDWithOptionsContext(ctx context.Context, db Executor, id string, options *QueryOptions) (*Node[T], error) {
+	fil := FilterSet{
+		NewFilter("id", id),
+	}
+
+	return s.GetByWithOptionsContext(ctx, db, fil, options)
+}
+
+// GetBy retuns a single typed node by filters
+func (s NodeStore[T]) GetBy(db Executor, filters FilterSet) (*Node[T], error) {
+	return s.GetByContext(context.Background(), db, filters)
+}
+
+func (s NodeStore[T]) GetByContext(ctx context.Context, db Executor, filters FilterSet) (*Node[T], error) {
+	return s.GetByWithOptionsContext(ctx, db, filters, nil)
+}
+
+// GetByWithOptions returns a single typed node by filters, the options
+// can include inactive nodes
+func (s NodeStore[T]) GetByWithOptions(db Executor, filters FilterSet, options *QueryOptions) (*Node[T], error) {
+	return s.GetByWithOptionsContext(context.Background(), db, filters, options)
+}
+
+func (s NodeStore[T]) GetByWithOptionsContext(ctx context.Context, db Executor, filters FilterSet, options *QueryOptions) (*Node[T], error) {
+	nodes, err := s.GetManyByWithOptionsContext(ctx, db, &filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodes == nil || len(*nodes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &(*nodes)[0], nil
+}
+
+// GetManyBy will return a typed NodeSet and can be extended using a FilterSet.
+// Only active nodes are returned, see GetManyByWithOptions to include inactive ones
+func (s NodeStore[T]) GetManyBy(db Executor, filters *FilterSet) (*NodeSet[T], error) {
+	return s.GetManyByContext(context.Background(), db, filters)
+}
+
+func (s NodeStore[T]) GetManyByContext(ctx context.Context, db Executor, filters *FilterSet) (*NodeSet[T], error) {
+	return s.GetManyByWithOptionsContext(ctx, db, filters, nil)
+}
+
+// GetManyByWithOptions will return a typed NodeSet that can be extended using
+// a FilterSet and ordered, limited, or paged with QueryOptions. The node
+// table is aliased as n, see NodeProp
+func (s NodeStore[T]) GetManyByWithOptions(db Executor, filters *FilterSet, options *QueryOptions) (*NodeSet[T], error) {
+	return s.GetManyByWithOptionsContext(context.Background(), db, filters, options)
+}
+
+func (s NodeStore[T]) GetManyByWithOptionsContext(ctx context.Context, db Executor, filters *FilterSet, options *QueryOptions) (*NodeSet[T], error) {
+	query, params, err := s.graph.queryByOptions(s.graph.nodeTableName, "n", filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	nodes, err := RowsToNode[T](res)
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,80 @@
+package pyt
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestGetByIDWithOptionsIncludesInactive(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	_, err := g.NodeSoftDelete(db, "kram")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = g.EdgeSoftDelete(db, "mark-jules")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inactive := &QueryOptions{IncludeInactive: true}
+
+	_, err = Nodes[testUser](g).GetByID(db, "kram")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for an inactive node, got %v", err)
+	}
+
+	node, err := Nodes[testUser](g).GetByIDWithOptions(db, "kram", inactive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if node.ID != "kram" || node.Active {
+		t.Fatalf("expected the inactive kram node, got %+v", node)
+	}
+
+	node, err = Nodes[testUser](g).GetByWithOptions(db, FilterSet{NewFilter(NodeProp("username"), "kram")}, inactive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if node.ID != "kram" {
+		t.Fatalf("expected kram, got %s", node.ID)
+	}
+
+	_, err = Edges[testFollows](g).GetByID(db, "mark-jules")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for an inactive edge, got %v", err)
+	}
+
+	edge, err := Edges[testFollows](g).GetByIDWithOptions(db, "mark-jules", inactive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if edge.ID != "mark-jules" || edge.Active {
+		t.Fatalf("expected the inactive mark-jules edge, got %+v", edge)
+	}
+
+	// the package functions on the graph's tables
+	node, err = NodeGetByIDWithOptionsAndTableName[testUser](db, g.NodeTableName(), "kram", inactive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if node.ID != "kram" {
+		t.Fatalf("expected kram, got %s", node.ID)
+	}
+
+	edge, err = EdgeGetByIDWithOptionsAndTableName[testFollows](db, g.EdgeTableName(), "mark-jules", inactive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if edge.ID != "mark-jules" {
+		t.Fatalf("expected mark-jules, got %s", edge.ID)
+	}
+}
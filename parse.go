@@ -0,0 +1,772 @@
+package pyt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrBadFilterSyntax error = errors.New("bad filter syntax")
+	ErrUnknownType     error = errors.New("type is not registered")
+)
+
+// FilterParser turns a filter string, or a json filter document, into a
+// FilterSet. It is meant for APIs that accept filters from their users.
+//
+// The filter language compares columns and properties to values and
+// combines the comparisons with and, or, not, and parentheses. and binds
+// tighter than or. Keywords are case insensitive
+//
+//	username = 'mark' and (loc is null or loc like 'n%')
+//	not type in ('user', 'admin')
+//	time_created between '2024-01-01' and '2024-02-01'
+//	tags contains "golang"
+//
+// Fields are the id, type, active, time_created, and time_updated columns,
+// in_id and out_id for edges, or a dot separated property path. A property
+// that shares its name with a column is written as properties.name.
+// Strings are single or double quoted, a quote is escaped by doubling it.
+// Numbers, true, and false are also values. The operators are = != <> < <=
+// > >= like, glob, in, not in, between, contains, is null, and is not null
+//
+// The json document is a comparison, an and, or, or not, or an array of
+// documents that are combined with and. op defaults to =, in and between
+// take an array value, and is null takes no value. The arrays of and, or,
+// and the top level need at least one document
+//
+//	{"and": [
+//		{"field": "username", "value": "mark"},
+//		{"or": [{"field": "loc", "op": "is null"}, {"field": "loc", "op": "like", "value": "n%"}]},
+//		{"not": {"field": "type", "op": "in", "value": ["user", "admin"]}}
+//	]}
+type FilterParser struct {
+	alias      string
+	columns    map[string]bool
+	properties map[string]bool
+}
+
+// NewFilterParser creates a parser whose fields are prefixed with alias,
+// "n" for node queries and "e" for edge queries, or an empty alias for
+// NodesGetBy and EdgesGetBy. Any property is allowed
+func NewFilterParser(alias string) *FilterParser {
+	return &FilterParser{
+		alias:   alias,
+		columns: columns,
+	}
+}
+
+// NodeFilterParser creates a parser for nodes of a registered type. Fields
+// are prefixed with n and only the properties of the type are allowed
+func (r *Registry) NodeFilterParser(nodeType string) (*FilterParser, error) {
+	schema, ok := r.NodeSchema(nodeType)
+	if !ok {
+		return nil, fmt.Errorf(`%w: node %s`, ErrUnknownType, nodeType)
+	}
+
+	return &FilterParser{
+		alias:      "n",
+		columns:    nodeColumns,
+		properties: schemaFields(schema.GoType),
+	}, nil
+}
+
+// EdgeFilterParser creates a parser for edges of a registered type. Fields
+// are prefixed with e and only the properties of the type are allowed
+func (r *Registry) EdgeFilterParser(edgeType string) (*FilterParser, error) {
+	schema, ok := r.EdgeSchema(edgeType)
+	if !ok {
+		return nil, fmt.Errorf(`%w: edge %s`, ErrUnknownType, edgeType)
+	}
+
+	return &FilterParser{
+		alias:      "e",
+		columns:    edgeColumns,
+		properties: schemaFields(schema.GoType),
+	}, nil
+}
+
+// ParseFilter parses the filter language without an alias or property
+// checks. See FilterParser
+func ParseFilter(input string) (FilterSet, error) {
+	return NewFilterParser("").Parse(input)
+}
+
+// ParseFilterJSON parses a json filter document without an alias or
+// property checks. See FilterParser
+func ParseFilterJSON(data []byte) (FilterSet, error) {
+	return NewFilterParser("").ParseJSON(data)
+}
+
+var (
+	nodeColumns = map[string]bool{
+		"id":           true,
+		"active":       true,
+		"type":         true,
+		"time_created": true,
+		"time_updated": true,
+	}
+	edgeColumns = map[string]bool{
+		"id":           true,
+		"active":       true,
+		"type":         true,
+		"time_created": true,
+		"time_updated": true,
+		"in_id":        true,
+		"out_id":       true,
+	}
+)
+
+// schemaFields returns the json names of a struct's fields, nil allows any
+// property
+func schemaFields(goType reflect.Type) map[string]bool {
+	for goType.Kind() == reflect.Pointer {
+		goType = goType.Elem()
+	}
+
+	if goType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return jsonFields(goType)
+}
+
+// field converts a parsed field into a column or a Prop
+func (p *FilterParser) field(name string) (string, error) {
+	alias := p.alias
+	if alias != "" {
+		alias = alias + "."
+	}
+
+	if p.columns[name] && name != "properties" {
+		return alias + name, nil
+	}
+
+	property := strings.TrimPrefix(name, "properties.")
+	if property == "" || property == "properties" {
+		return "", fmt.Errorf(`%w: %q`, ErrBadField, name)
+	}
+
+	key, _, _ := strings.Cut(property, ".")
+	if p.properties != nil && !p.properties[key] {
+		return "", fmt.Errorf(`%w: %q`, ErrUnknownProperty, key)
+	}
+
+	return PropField(p.alias, property)
+}
+
+// comparison builds the filter for a single comparison. The value is a
+// slice for in, not in, and between
+func (p *FilterParser) comparison(name, operator string, value any) (*filter, error) {
+	field, err := p.field(name)
+	if err != nil {
+		return nil, err
+	}
+
+	operator = normalizeOperator(operator)
+	if operator == "" {
+		operator = "="
+	}
+
+	switch operator {
+	case "=", "==", "!=", "<>", "<", "<=", ">", ">=", "LIKE", "GLOB", "CONTAINS":
+		if value == nil || isList(value) {
+			return nil, fmt.Errorf(`%w: %s needs a single value`, ErrBadFilterSyntax, operator)
+		}
+
+		return NewFilterFull(field, operator, value, "and"), nil
+	case "IN", "NOT IN":
+		if !isList(value) {
+			return nil, fmt.Errorf(`%w: %s needs a list of values`, ErrBadFilterSyntax, operator)
+		}
+
+		return NewFilterFull(field, operator, value, "and"), nil
+	case "BETWEEN":
+		if !isList(value) || len(filterValues(value)) != 2 {
+			return nil, fmt.Errorf(`%w: BETWEEN needs two values`, ErrBadFilterSyntax)
+		}
+
+		values := filterValues(value)
+
+		return NewBetweenFilter(field, values[0], values[1]), nil
+	case "IS NULL":
+		return NewIsNullFilter(field), nil
+	case "IS NOT NULL":
+		return NewIsNotNullFilter(field), nil
+	}
+
+	return nil, fmt.Errorf(`%w: %q`, ErrBadOperator, operator)
+}
+
+func isList(value any) bool {
+	_, ok := value.([]any)
+
+	return ok
+}
+
+// Parse parses the filter language into a FilterSet
+func (p *FilterParser) Parse(input string) (FilterSet, error) {
+	tokens, err := tokenizeFilter(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return FilterSet{}, nil
+	}
+
+	parser := filterTokens{
+		parser: p,
+		tokens: tokens,
+	}
+
+	e, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if !parser.done() {
+		return nil, parser.unexpected()
+	}
+
+	return FilterSet{e}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type filterToken struct {
+	kind     tokenKind
+	text     string
+	value    any
+	position int
+}
+
+// keyword reports if the token is the case insensitive keyword
+func (t filterToken) keyword(word string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, word)
+}
+
+// tokenizeFilter splits the filter language into words, strings, numbers,
+// and symbols
+func tokenizeFilter(input string) ([]filterToken, error) {
+	tokens := []filterToken{}
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			text := strings.Builder{}
+			start := i
+			i++
+
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf(`%w: unterminated string at %d`, ErrBadFilterSyntax, start)
+				}
+
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						text.WriteRune(r)
+						i += 2
+						continue
+					}
+
+					i++
+					break
+				}
+
+				text.WriteRune(runes[i])
+				i++
+			}
+
+			tokens = append(tokens, filterToken{kind: tokenString, value: text.String(), position: start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			text := string(runes[start:i])
+			value, err := parseNumber(text)
+			if err != nil {
+				return nil, fmt.Errorf(`%w: bad number %q at %d`, ErrBadFilterSyntax, text, start)
+			}
+
+			tokens = append(tokens, filterToken{kind: tokenNumber, text: text, value: value, position: start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '.' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+
+			tokens = append(tokens, filterToken{kind: tokenWord, text: string(runes[start:i]), position: start})
+		default:
+			start := i
+			symbol := string(r)
+
+			if i+1 < len(runes) {
+				switch pair := string(runes[i : i+2]); pair {
+				case "!=", "<>", "<=", ">=", "==":
+					symbol = pair
+				}
+			}
+
+			switch symbol {
+			case "=", "==", "!=", "<>", "<", "<=", ">", ">=", "(", ")", ",":
+			default:
+				return nil, fmt.Errorf(`%w: unexpected %q at %d`, ErrBadFilterSyntax, symbol, start)
+			}
+
+			i += len([]rune(symbol))
+			tokens = append(tokens, filterToken{kind: tokenSymbol, text: symbol, position: start})
+		}
+	}
+
+	return tokens, nil
+}
+
+func parseNumber(text string) (any, error) {
+	if !strings.Contains(text, ".") {
+		return strconv.ParseInt(text, 10, 64)
+	}
+
+	return strconv.ParseFloat(text, 64)
+}
+
+// filterTokens is a recursive descent parser over the tokens
+type filterTokens struct {
+	parser *FilterParser
+	tokens []filterToken
+	next   int
+}
+
+func (t *filterTokens) done() bool {
+	return t.next >= len(t.tokens)
+}
+
+func (t *filterTokens) peek() filterToken {
+	if t.done() {
+		return filterToken{}
+	}
+
+	return t.tokens[t.next]
+}
+
+func (t *filterTokens) unexpected() error {
+	if t.done() {
+		return fmt.Errorf(`%w: unexpected end of filter`, ErrBadFilterSyntax)
+	}
+
+	token := t.peek()
+	text := token.text
+	if token.kind == tokenString {
+		text = fmt.Sprintf(`%v`, token.value)
+	}
+
+	return fmt.Errorf(`%w: unexpected %q at %d`, ErrBadFilterSyntax, text, token.position)
+}
+
+// accept consumes the next token when it is the keyword or symbol
+func (t *filterTokens) accept(text string) bool {
+	token := t.peek()
+	if token.keyword(text) || (token.kind == tokenSymbol && token.text == text) {
+		t.next++
+		return true
+	}
+
+	return false
+}
+
+func (t *filterTokens) expect(text string) error {
+	if !t.accept(text) {
+		return t.unexpected()
+	}
+
+	return nil
+}
+
+func (t *filterTokens) or() (Expression, error) {
+	expressions := []Expression{}
+
+	for {
+		e, err := t.and()
+		if err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, e)
+
+		if !t.accept("or") {
+			break
+		}
+	}
+
+	if len(expressions) == 1 {
+		return expressions[0], nil
+	}
+
+	return Or(expressions...), nil
+}
+
+func (t *filterTokens) and() (Expression, error) {
+	expressions := []Expression{}
+
+	for {
+		e, err := t.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, e)
+
+		if !t.accept("and") {
+			break
+		}
+	}
+
+	if len(expressions) == 1 {
+		return expressions[0], nil
+	}
+
+	return And(expressions...), nil
+}
+
+func (t *filterTokens) unary() (Expression, error) {
+	if t.accept("not") {
+		e, err := t.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return Not(e), nil
+	}
+
+	if t.accept("(") {
+		e, err := t.or()
+		if err != nil {
+			return nil, err
+		}
+
+		return e, t.expect(")")
+	}
+
+	return t.comparison()
+}
+
+func (t *filterTokens) comparison() (Expression, error) {
+	field := t.peek()
+	if field.kind != tokenWord || isFilterKeyword(field.text) {
+		return nil, t.unexpected()
+	}
+
+	t.next++
+
+	var operator string
+	var value any
+	var err error
+
+	switch token := t.peek(); {
+	case token.kind == tokenSymbol && token.text != "(" && token.text != ")" && token.text != ",":
+		t.next++
+		operator = token.text
+		value, err = t.value()
+	case token.keyword("like"), token.keyword("glob"), token.keyword("contains"):
+		t.next++
+		operator = token.text
+		value, err = t.value()
+	case token.keyword("in"):
+		t.next++
+		operator = "in"
+		value, err = t.list()
+	case token.keyword("not"):
+		t.next++
+		switch {
+		case t.accept("in"):
+			operator = "not in"
+			value, err = t.list()
+		case t.accept("like"):
+			operator = "not like"
+			value, err = t.value()
+		case t.accept("glob"):
+			operator = "not glob"
+			value, err = t.value()
+		default:
+			return nil, t.unexpected()
+		}
+	case token.keyword("between"):
+		t.next++
+		operator = "between"
+
+		var low, high any
+		low, err = t.value()
+		if err == nil {
+			err = t.expect("and")
+		}
+
+		if err == nil {
+			high, err = t.value()
+		}
+
+		value = []any{low, high}
+	case token.keyword("is"):
+		t.next++
+		operator = "is null"
+		if t.accept("not") {
+			operator = "is not null"
+		}
+
+		err = t.expect("null")
+	default:
+		return nil, t.unexpected()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// not like and not glob are negated like filters, they are not on the
+	// operator allow list
+	operator = normalizeOperator(operator)
+	if negated, ok := strings.CutPrefix(operator, "NOT "); ok && negated != "IN" {
+		f, err := t.parser.comparison(field.text, negated, value)
+		if err != nil {
+			return nil, err
+		}
+
+		return Not(f), nil
+	}
+
+	return t.parser.comparison(field.text, operator, value)
+}
+
+// value consumes a string, number, true, or false
+func (t *filterTokens) value() (any, error) {
+	token := t.peek()
+
+	switch {
+	case token.kind == tokenString, token.kind == tokenNumber:
+		t.next++
+		return token.value, nil
+	case token.keyword("true"):
+		t.next++
+		return true, nil
+	case token.keyword("false"):
+		t.next++
+		return false, nil
+	}
+
+	return nil, t.unexpected()
+}
+
+// list consumes a parenthesized, comma separated list of values
+func (t *filterTokens) list() (any, error) {
+	err := t.expect("(")
+	if err != nil {
+		return nil, err
+	}
+
+	values := []any{}
+	if t.accept(")") {
+		return values, nil
+	}
+
+	for {
+		value, err := t.value()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+
+		if t.accept(")") {
+			return values, nil
+		}
+
+		err = t.expect(",")
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func isFilterKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "or", "not", "in", "between", "is", "null", "like", "glob", "contains", "true", "false":
+		return true
+	}
+
+	return false
+}
+
+// jsonFilter is a single node of a json filter document
+type jsonFilter struct {
+	And   []json.RawMessage `json:"and"`
+	Or    []json.RawMessage `json:"or"`
+	Not   json.RawMessage   `json:"not"`
+	Field string            `json:"field"`
+	Op    string            `json:"op"`
+	Value any               `json:"value"`
+}
+
+// ParseJSON parses a json filter document into a FilterSet
+func (p *FilterParser) ParseJSON(data []byte) (FilterSet, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return FilterSet{}, nil
+	}
+
+	e, err := p.jsonExpression(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterSet{e}, nil
+}
+
+func (p *FilterParser) jsonExpression(data json.RawMessage) (Expression, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		documents := []json.RawMessage{}
+
+		err := json.Unmarshal(data, &documents)
+		if err != nil {
+			return nil, fmt.Errorf(`%w: %w`, ErrBadFilterSyntax, err)
+		}
+
+		return p.jsonExpressions("and", And, documents)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+
+	document := jsonFilter{}
+
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, fmt.Errorf(`%w: %w`, ErrBadFilterSyntax, err)
+	}
+
+	// the document must be the only thing in the data
+	end := decoder.InputOffset()
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf(`%w: unexpected data after the filter at %d`, ErrBadFilterSyntax, end)
+	}
+
+	set := 0
+	for _, ok := range []bool{document.And != nil, document.Or != nil, document.Not != nil, document.Field != ""} {
+		if ok {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return nil, fmt.Errorf(`%w: a filter needs exactly one of and, or, not, or field`, ErrBadFilterSyntax)
+	}
+
+	switch {
+	case document.And != nil:
+		return p.jsonExpressions("and", And, document.And)
+	case document.Or != nil:
+		return p.jsonExpressions("or", Or, document.Or)
+	case document.Not != nil:
+		e, err := p.jsonExpression(document.Not)
+		if err != nil {
+			return nil, err
+		}
+
+		return Not(e), nil
+	}
+
+	value, err := jsonValue(document.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := normalizeOperator(document.Op)
+	if negated, ok := strings.CutPrefix(operator, "NOT "); ok && negated != "IN" {
+		f, err := p.comparison(document.Field, negated, value)
+		if err != nil {
+			return nil, err
+		}
+
+		return Not(f), nil
+	}
+
+	return p.comparison(document.Field, operator, value)
+}
+
+// jsonExpressions parses the documents of an and, or a top level list, into
+// a junction. An empty list is rejected, an empty or would match nothing and
+// an empty and would match everything, neither is what a client means
+func (p *FilterParser) jsonExpressions(name string, junction func(...Expression) Expression, documents []json.RawMessage) (Expression, error) {
+	if len(documents) == 0 {
+		return nil, fmt.Errorf(`%w: %s needs at least one filter`, ErrBadFilterSyntax, name)
+	}
+
+	expressions := make([]Expression, len(documents))
+
+	for i, document := range documents {
+		e, err := p.jsonExpression(document)
+		if err != nil {
+			return nil, err
+		}
+
+		expressions[i] = e
+	}
+
+	return junction(expressions...), nil
+}
+
+// jsonValue converts a decoded json value into a value that can be bound.
+// Numbers become int64 or float64, objects are not allowed
+func jsonValue(value any) (any, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+
+		return v.Float64()
+	case []any:
+		values := make([]any, len(v))
+
+		for i, item := range v {
+			if _, ok := item.([]any); ok {
+				return nil, fmt.Errorf(`%w: nested lists are not values`, ErrBadFilterSyntax)
+			}
+
+			value, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+
+			values[i] = value
+		}
+
+		return values, nil
+	case map[string]any:
+		return nil, fmt.Errorf(`%w: objects are not values`, ErrBadFilterSyntax)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,58 @@
+package pyt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseJSONRejectsEmptyJunctions(t *testing.T) {
+	documents := []string{
+		`{"or": []}`,
+		`{"and": []}`,
+		`[]`,
+		`{"not": {"or": []}}`,
+		`{"and": [{"field": "username", "value": "mark"}, {"or": []}]}`,
+	}
+
+	for _, document := range documents {
+		t.Run(document, func(t *testing.T) {
+			_, err := ParseFilterJSON([]byte(document))
+			if !errors.Is(err, ErrBadFilterSyntax) {
+				t.Fatalf("expected ErrBadFilterSyntax, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseJSONJunctions(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedFollows(t, g, db)
+
+	tests := []struct {
+		document string
+		expected int
+	}{
+		{document: `{"or": [{"field": "username", "value": "mark"}]}`, expected: 1},
+		{document: `{"and": [{"field": "username", "value": "mark"}]}`, expected: 1},
+		{document: `{"or": [{"field": "username", "value": "mark"}, {"field": "age", "op": "<", "value": 30}]}`, expected: 2},
+		{document: `{"and": [{"field": "age", "op": ">", "value": 30}, {"not": {"field": "username", "value": "ana"}}]}`, expected: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.document, func(t *testing.T) {
+			filters, err := ParseFilterJSON([]byte(test.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nodes, err := Nodes[testUser](g).GetManyBy(db, &filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(*nodes) != test.expected {
+				t.Fatalf("expected %d nodes, got %d", test.expected, len(*nodes))
+			}
+		})
+	}
+}
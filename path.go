@@ -0,0 +1,541 @@
+package pyt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxDepth is used when PathOptions.MaxDepth is not set
+const DefaultMaxDepth int = 6
+
+var (
+	ErrBadDirection error = errors.New("direction must be in, out, or both")
+	ErrNoPath       error = errors.New("no path exists between the nodes")
+)
+
+// PathOptions controls how a variable length walk moves across the edge table
+type PathOptions struct {
+	// Direction is one of "out", "in", or "both" and defaults to "out"
+	Direction string
+
+	// EdgeTypes limits the walk to edges of these types, all edge types
+	// are followed when it is empty
+	EdgeTypes []string
+
+	// MaxDepth is the maximum number of hops that will be walked and
+	// defaults to DefaultMaxDepth
+	MaxDepth int
+
+	// IncludeInactive will walk soft deleted edges and nodes. By default
+	// only active edges and nodes are walked
+	IncludeInactive bool
+}
+
+// ReachedNode is a node that was found during a variable length walk along
+// with the number of hops it took to get there and the ids of the edges
+// that were walked, in order
+type ReachedNode struct {
+	GenericNode
+	Depth int
+	Path  []string
+}
+
+type ReachedNodeSet []ReachedNode
+
+func (rs ReachedNodeSet) IDs() []string {
+	ids := make([]string, len(rs))
+
+	for i, r := range rs {
+		ids[i] = r.ID
+	}
+
+	return ids
+}
+
+// walkStep is how a walk first reached a node. The parent is the node that
+// the edge was walked from, the start node has no parent
+type walkStep struct {
+	depth  int
+	parent string
+	edgeID string
+}
+
+// walkDirection returns the sql that moves a walk across an edge e. join
+// finds the edges that leave w.node_id, next is the node that the edge
+// reaches, and parent matches an edge that leads from p.node_id to r.node_id
+func walkDirection(direction string) (join, next, parent string, err error) {
+	switch direction {
+	case "", "out":
+		return "e.in_id = w.node_id",
+			"e.out_id",
+			"e.in_id = p.node_id AND e.out_id = r.node_id",
+			nil
+	case "in":
+		return "e.out_id = w.node_id",
+			"e.in_id",
+			"e.out_id = p.node_id AND e.in_id = r.node_id",
+			nil
+	case "both":
+		return "(e.in_id = w.node_id OR e.out_id = w.node_id)",
+			"CASE WHEN e.in_id = w.node_id THEN e.out_id ELSE e.in_id END",
+			"((e.in_id = p.node_id AND e.out_id = r.node_id) OR (e.out_id = p.node_id AND e.in_id = r.node_id))",
+			nil
+	}
+
+	return "", "", "", ErrBadDirection
+}
+
+// walkEdgeTypes limits the edges of a walk to the options' edge types
+func walkEdgeTypes(options PathOptions, params *[]any) string {
+	if len(options.EdgeTypes) == 0 {
+		return ""
+	}
+
+	holders := make([]string, len(options.EdgeTypes))
+
+	for i, edgeType := range options.EdgeTypes {
+		holders[i] = "?"
+		*params = append(*params, edgeType)
+	}
+
+	return fmt.Sprintf(`AND
+		e.type IN (%s)`, strings.Join(holders, ", "))
+}
+
+// walkActive limits the edges of a walk, and the nodes that they reach, to
+// the active ones unless the options include inactive records
+func walkActive(nodeTableName, reached string, options PathOptions) string {
+	if options.IncludeInactive {
+		return ""
+	}
+
+	return fmt.Sprintf(`JOIN
+		%s n ON n.id = %s AND %s`, nodeTableName, reached, activeClause("e", "n"))
+}
+
+// buildWalk creates a recursive cte named walk with a row for every node that
+// can be reached from nodeID at each depth up to options.MaxDepth. The rows
+// are combined with UNION, so a node is walked at most once per depth and a
+// cycle cannot multiply the rows. The minimal depth of a node is the shortest
+// number of hops to it
+func buildWalk(nodeTableName, edgeTableName, nodeID string, options PathOptions, params *[]any) (string, error) {
+	maxDepth := options.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	join, next, _, err := walkDirection(options.Direction)
+	if err != nil {
+		return "", err
+	}
+
+	*params = append(*params, nodeID, maxDepth)
+	edgeTypes := walkEdgeTypes(options, params)
+
+	walk := fmt.Sprintf(`
+	WITH RECURSIVE walk(node_id, depth) AS (
+		SELECT
+			?, 0
+		UNION
+		SELECT
+			%[2]s,
+			w.depth + 1
+		FROM
+			walk w
+		JOIN
+			%[1]s e ON %[3]s
+		%[5]s
+		WHERE
+			w.depth < ?
+		%[4]s
+	)`, edgeTableName, next, join, edgeTypes, walkActive(nodeTableName, next, options))
+
+	return walk, nil
+}
+
+// buildWalkSteps extends the walk with the minimal depth of every reached
+// node and returns a row for each edge that leads to a node from a node one
+// hop closer to the start. Those rows are the parent links of the shortest
+// paths, the start node is not included
+func buildWalkSteps(nodeTableName, edgeTableName, nodeID string, options PathOptions, params *[]any) (string, error) {
+	walk, err := buildWalk(nodeTableName, edgeTableName, nodeID, options, params)
+	if err != nil {
+		return "", err
+	}
+
+	_, _, parent, err := walkDirection(options.Direction)
+	if err != nil {
+		return "", err
+	}
+
+	edgeTypes := walkEdgeTypes(options, params)
+
+	query := fmt.Sprintf(`%[1]s,
+	reached(node_id, depth) AS (
+		SELECT
+			node_id,
+			MIN(depth)
+		FROM
+			walk
+		GROUP BY
+			node_id
+	)
+	SELECT
+		r.node_id,
+		r.depth,
+		p.node_id,
+		e.id
+	FROM
+		reached r
+	JOIN
+		reached p ON p.depth = r.depth - 1
+	JOIN
+		%[2]s e ON %[3]s
+	%[5]s
+	WHERE
+		r.depth > 0
+	%[4]s
+	ORDER BY
+		r.depth, r.node_id, e.id
+	`, walk, edgeTableName, parent, edgeTypes, walkActive(nodeTableName, "r.node_id", options))
+
+	return query, nil
+}
+
+// walk runs the recursive walk from nodeID in a single query. The steps are
+// keyed by node id, the start node included, and reached lists the other
+// nodes ordered by their depth and id
+func (g *Graph) walk(ctx context.Context, db Executor, nodeID string, options PathOptions) (map[string]walkStep, []string, error) {
+	params := []any{}
+
+	query, err := buildWalkSteps(g.nodeTableName, g.edgeTableName, nodeID, options, &params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	steps := map[string]walkStep{nodeID: {}}
+	reached := []string{}
+
+	for rows.Next() {
+		var id, parent, edgeID string
+		var depth int
+
+		err := rows.Scan(&id, &depth, &parent, &edgeID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the first edge, by id, is the parent link
+		if _, ok := steps[id]; ok {
+			continue
+		}
+
+		steps[id] = walkStep{depth: depth, parent: parent, edgeID: edgeID}
+		reached = append(reached, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return steps, reached, nil
+}
+
+// walkedPath follows the steps back from nodeID to the start of the walk and
+// returns the node ids and the edge ids, in order, that lead to it
+func walkedPath(steps map[string]walkStep, nodeID string) ([]string, []string) {
+	nodeIDs := []string{nodeID}
+	edgeIDs := []string{}
+
+	for step := steps[nodeID]; step.depth > 0; step = steps[step.parent] {
+		nodeIDs = append(nodeIDs, step.parent)
+		edgeIDs = append(edgeIDs, step.edgeID)
+	}
+
+	slices.Reverse(nodeIDs)
+	slices.Reverse(edgeIDs)
+
+	return nodeIDs, edgeIDs
+}
+
+// NodesReachableBy returns every node that can be reached from nodeID within
+// options.MaxDepth hops. Each node is returned once along with the shortest
+// depth and path that reached it. Paths never visit the same node twice
+func NodesReachableBy(db Executor, nodeID string, options PathOptions) (*ReachedNodeSet, error) {
+	return defaultGraph().NodesReachableBy(db, nodeID, options)
+}
+
+func NodesReachableByContext(ctx context.Context, db Executor, nodeID string, options PathOptions) (*ReachedNodeSet, error) {
+	return defaultGraph().NodesReachableByContext(ctx, db, nodeID, options)
+}
+
+func NodesReachableByWithTableName(db Executor, nodeTableName, edgeTableName, nodeID string, options PathOptions) (*ReachedNodeSet, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodesReachableBy(db, nodeID, options)
+}
+
+// NodesReachableBy returns every node that can be reached from nodeID within
+// options.MaxDepth hops. Each node is returned once along with the shortest
+// depth and path that reached it. Paths never visit the same node twice
+func (g *Graph) NodesReachableBy(db Executor, nodeID string, options PathOptions) (*ReachedNodeSet, error) {
+	return g.NodesReachableByContext(context.Background(), db, nodeID, options)
+}
+
+func (g *Graph) NodesReachableByContext(ctx context.Context, db Executor, nodeID string, options PathOptions) (*ReachedNodeSet, error) {
+	steps, reached, err := g.walk(ctx, db, nodeID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := ReachedNodeSet{}
+	if len(reached) == 0 {
+		return &resp, nil
+	}
+
+	nodes, err := pathNodes(ctx, db, g.nodeTableName, reached)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range reached {
+		node, ok := nodes[id]
+		if !ok {
+			continue
+		}
+
+		_, edgeIDs := walkedPath(steps, id)
+		resp = append(resp, ReachedNode{
+			GenericNode: node,
+			Depth:       steps[id].depth,
+			Path:        edgeIDs,
+		})
+	}
+
+	sort.SliceStable(resp, func(i, j int) bool {
+		if resp[i].Depth != resp[j].Depth {
+			return resp[i].Depth < resp[j].Depth
+		}
+
+		return resp[i].ID < resp[j].ID
+	})
+
+	return &resp, nil
+}
+
+// NodeIsReachable checks if toID can be reached from fromID within
+// options.MaxDepth hops
+func NodeIsReachable(db Executor, fromID, toID string, options PathOptions) (bool, error) {
+	return defaultGraph().NodeIsReachable(db, fromID, toID, options)
+}
+
+func NodeIsReachableContext(ctx context.Context, db Executor, fromID, toID string, options PathOptions) (bool, error) {
+	return defaultGraph().NodeIsReachableContext(ctx, db, fromID, toID, options)
+}
+
+func NodeIsReachableWithTableName(db Executor, nodeTableName, edgeTableName, fromID, toID string, options PathOptions) (bool, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodeIsReachable(db, fromID, toID, options)
+}
+
+// NodeIsReachable checks if toID can be reached from fromID within
+// options.MaxDepth hops
+func (g *Graph) NodeIsReachable(db Executor, fromID, toID string, options PathOptions) (bool, error) {
+	return g.NodeIsReachableContext(context.Background(), db, fromID, toID, options)
+}
+
+func (g *Graph) NodeIsReachableContext(ctx context.Context, db Executor, fromID, toID string, options PathOptions) (bool, error) {
+	params := []any{}
+
+	walk, err := buildWalk(g.nodeTableName, g.edgeTableName, fromID, options, &params)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(`%s
+	SELECT EXISTS (
+		SELECT 1 FROM walk WHERE node_id = ? AND depth > 0
+	)
+	`, walk)
+	params = append(params, toID)
+
+	var reachable bool
+	err = db.QueryRowContext(ctx, query, params...).Scan(&reachable)
+	if err != nil {
+		return false, err
+	}
+
+	return reachable, nil
+}
+
+// Path is an ordered walk across the graph. Nodes starts with the node the
+// walk started from and ends with the node it ended at, Edges[i] connects
+// Nodes[i] and Nodes[i+1]
+type Path struct {
+	Nodes []GenericNode
+	Edges []GenericEdge
+}
+
+// ShortestPath finds the shortest path between fromID and toID. ErrNoPath
+// is returned when toID cannot be reached within options.MaxDepth hops
+func ShortestPath(db Executor, fromID, toID string, options PathOptions) (*Path, error) {
+	return defaultGraph().ShortestPath(db, fromID, toID, options)
+}
+
+func ShortestPathContext(ctx context.Context, db Executor, fromID, toID string, options PathOptions) (*Path, error) {
+	return defaultGraph().ShortestPathContext(ctx, db, fromID, toID, options)
+}
+
+func ShortestPathWithTableName(db Executor, nodeTableName, edgeTableName, fromID, toID string, options PathOptions) (*Path, error) {
+	return tableGraph(nodeTableName, edgeTableName).ShortestPath(db, fromID, toID, options)
+}
+
+// ShortestPath finds the shortest path between fromID and toID. ErrNoPath
+// is returned when toID cannot be reached within options.MaxDepth hops
+func (g *Graph) ShortestPath(db Executor, fromID, toID string, options PathOptions) (*Path, error) {
+	return g.ShortestPathContext(context.Background(), db, fromID, toID, options)
+}
+
+func (g *Graph) ShortestPathContext(ctx context.Context, db Executor, fromID, toID string, options PathOptions) (*Path, error) {
+	// every parent link is one hop closer to the start, so following them
+	// back from toID is one of the shortest paths
+	steps, _, err := g.walk(ctx, db, fromID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := steps[toID]; !ok {
+		return nil, ErrNoPath
+	}
+
+	nodeIDs, edgeIDs := walkedPath(steps, toID)
+
+	nodes, err := pathNodes(ctx, db, g.nodeTableName, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := pathEdges(ctx, db, g.edgeTableName, edgeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	path := Path{
+		Nodes: make([]GenericNode, len(nodeIDs)),
+		Edges: make([]GenericEdge, len(edgeIDs)),
+	}
+
+	for i, id := range nodeIDs {
+		node, ok := nodes[id]
+		if !ok {
+			return nil, ErrNoPath
+		}
+
+		path.Nodes[i] = node
+	}
+
+	for i, id := range edgeIDs {
+		edge, ok := edges[id]
+		if !ok {
+			return nil, ErrNoPath
+		}
+
+		path.Edges[i] = edge
+	}
+
+	return &path, nil
+}
+
+// idsQuery builds a select for all of the ids in tableName. The ids are
+// passed as a single json array so that a long path, or every node reached
+// by a walk, does not run into sqlite's limit on parameters
+func idsQuery(tableName string, ids []string) (string, []any, error) {
+	params, err := json.Marshal(ids)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		*
+	FROM
+		%s
+	WHERE id IN (SELECT value FROM json_each(?))
+	`, tableName)
+
+	return query, []any{string(params)}, nil
+}
+
+// pathNodes loads the nodes for a path keyed by their id
+func pathNodes(ctx context.Context, db Executor, nodeTableName string, ids []string) (map[string]GenericNode, error) {
+	query, params, err := idsQuery(nodeTableName, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	nodes, err := RowsToNode[GenericProperties](rows)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := map[string]GenericNode{}
+	for _, node := range *nodes {
+		resp[node.ID] = GenericNode(node)
+	}
+
+	return resp, nil
+}
+
+// pathEdges loads the edges for a path keyed by their id
+func pathEdges(ctx context.Context, db Executor, edgeTableName string, ids []string) (map[string]GenericEdge, error) {
+	resp := map[string]GenericEdge{}
+	if len(ids) == 0 {
+		return resp, nil
+	}
+
+	query, params, err := idsQuery(edgeTableName, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	edges, err := RowsToEdge[GenericProperties](rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, edge := range *edges {
+		resp[edge.ID] = GenericEdge(edge)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,116 @@
+package pyt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// seedChain creates a chain of nodes, node-0 -> node-1 -> ... node-{length-1}
+func seedChain(t *testing.T, g *Graph, db Executor, length int) {
+	t.Helper()
+
+	nodes := make([]Node[testUser], length)
+	edges := make([]Edge[testFollows], length-1)
+
+	for i := range nodes {
+		id := fmt.Sprintf("node-%d", i)
+		nodes[i] = *NewNode(id, "user", testUser{Username: id})
+
+		if i > 0 {
+			edges[i-1] = *NewEdge(fmt.Sprintf("edge-%d", i), "follows", fmt.Sprintf("node-%d", i-1), id, testFollows{})
+		}
+	}
+
+	_, err := Nodes[testUser](g).CreateMany(db, nodes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Edges[testFollows](g).CreateMany(db, edges...)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkWithCancelledContext(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedChain(t, g, db, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	options := PathOptions{MaxDepth: 20}
+
+	_, err := g.NodesReachableByContext(ctx, db, "node-0", options)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("NodesReachableBy expected context.Canceled, got %v", err)
+	}
+
+	_, err = g.NodeIsReachableContext(ctx, db, "node-0", "node-9", options)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("NodeIsReachable expected context.Canceled, got %v", err)
+	}
+
+	_, err = g.ShortestPathContext(ctx, db, "node-0", "node-9", options)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ShortestPath expected context.Canceled, got %v", err)
+	}
+
+	_, err = Nodes[testUser](g).GetManyByContext(ctx, db, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetManyBy expected context.Canceled, got %v", err)
+	}
+}
+
+// seedClique creates size nodes, clique-1 through clique-{size}, with an edge
+// from every node to every other node
+func seedClique(t *testing.T, g *Graph, db Executor, size int) {
+	t.Helper()
+
+	_, err := db.ExecContext(context.Background(), fmt.Sprintf(`
+	WITH RECURSIVE counter(i) AS (
+		SELECT 1 UNION ALL SELECT i + 1 FROM counter WHERE i < ?
+	)
+	INSERT INTO %s (id, type, properties)
+	SELECT 'clique-' || i, 'user', '{}' FROM counter
+	`, g.NodeTableName()), size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.ExecContext(context.Background(), fmt.Sprintf(`
+	INSERT INTO %[1]s (id, type, in_id, out_id, properties)
+	SELECT a.id || '-' || b.id, 'follows', a.id, b.id, '{}'
+	FROM %[2]s a, %[2]s b
+	WHERE a.id != b.id
+	`, g.EdgeTableName(), g.NodeTableName()))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkCancelledPartWay(t *testing.T) {
+	g, db := newTestGraph(t)
+	seedClique(t, g, db, 100)
+
+	// every node of the clique is walked at every depth, the full walk
+	// takes several seconds
+	options := PathOptions{MaxDepth: 1000}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := g.NodesReachableByContext(ctx, db, "clique-1", options)
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the walk to stop at the deadline, it ran for %s", elapsed)
+	}
+}
@@ -0,0 +1,292 @@
+package pyt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrBadPropertyIndex      error = errors.New("bad property index")
+	ErrPropertyIndexExists   error = errors.New("property index exists with a different definition")
+	ErrPropertyIndexNotFound error = errors.New("property index not found")
+)
+
+// propertyIndexTableName stores the definition of every property index so
+// that upserts can rebuild the exact conflict target that the index uses
+const propertyIndexTableName string = "pyt_property_index"
+
+// PropertyIndex is an index on one or more properties of a node or edge
+// type. Each property is a dot separated path into the properties json. It
+// is created as a partial expression index:
+//
+// CREATE UNIQUE INDEX node_user_username_idx
+// ON node(type, json_extract(properties, '$.username'))
+// WHERE type = 'user'
+//
+// A unique PropertyIndex can be used by NodeUpsertByIndex and
+// EdgeUpsertByIndex in place of the raw conflict columns and clause
+type PropertyIndex struct {
+	// Name identifies the index within its graph. The sqlite index name is
+	// prefixed with the table name
+	Name string
+
+	// Type limits the index to nodes or edges of a type, leave it empty to
+	// index every type
+	Type string
+
+	Properties []string
+	Unique     bool
+
+	// Edge puts the index on the edge table instead of the node table
+	Edge bool
+}
+
+func (p PropertyIndex) tableName(g *Graph) string {
+	if p.Edge {
+		return g.edgeTableName
+	}
+
+	return g.nodeTableName
+}
+
+func (p PropertyIndex) indexName(g *Graph) string {
+	return fmt.Sprintf(`%s_%s`, p.tableName(g), p.Name)
+}
+
+// target returns the columns and where clause of the index. They are also
+// the conflict target of an upsert that uses the index
+func (p PropertyIndex) target() (string, string, error) {
+	if !propertyPathKey.MatchString(p.Name) {
+		return "", "", fmt.Errorf(`%w: bad name %q`, ErrBadPropertyIndex, p.Name)
+	}
+
+	if len(p.Properties) == 0 {
+		return "", "", fmt.Errorf(`%w: %s has no properties`, ErrBadPropertyIndex, p.Name)
+	}
+
+	columns := []string{}
+	if p.Type != "" {
+		columns = append(columns, "type")
+	}
+
+	for _, property := range p.Properties {
+		path, err := PropertyPath(property)
+		if err != nil {
+			return "", "", err
+		}
+
+		columns = append(columns, fmt.Sprintf(`json_extract(properties, '%s')`, path))
+	}
+
+	// the type is written as a literal, sqlite cannot match a partial index
+	// to an upsert's conflict target when the where clause is bound
+	var where string
+	if p.Type != "" {
+		where = fmt.Sprintf(`type = '%s'`, strings.ReplaceAll(p.Type, "'", "''"))
+	}
+
+	return strings.Join(columns, ", "), where, nil
+}
+
+// CreatePropertyIndex creates the index if it does not exist. Creating an
+// index that already exists with the same definition does nothing, a
+// different definition returns ErrPropertyIndexExists
+func (g *Graph) CreatePropertyIndex(db Executor, index PropertyIndex) error {
+	return g.CreatePropertyIndexContext(context.Background(), db, index)
+}
+
+func (g *Graph) CreatePropertyIndexContext(ctx context.Context, db Executor, index PropertyIndex) error {
+	return inTx(ctx, db, func(db Executor) error {
+		return g.createPropertyIndex(ctx, db, index)
+	})
+}
+
+// createPropertyIndex creates the index and records its definition, which
+// must happen together
+func (g *Graph) createPropertyIndex(ctx context.Context, db Executor, index PropertyIndex) error {
+	columns, where, err := index.target()
+	if err != nil {
+		return err
+	}
+
+	existing, err := g.PropertyIndexContext(ctx, db, index.Name)
+	if err != nil && !errors.Is(err, ErrPropertyIndexNotFound) {
+		return err
+	}
+
+	if existing != nil {
+		existingColumns, existingWhere, err := existing.target()
+		if err != nil {
+			return err
+		}
+
+		if existingColumns != columns || existingWhere != where || existing.Unique != index.Unique || existing.Edge != index.Edge {
+			return fmt.Errorf(`%w: %s`, ErrPropertyIndexExists, index.Name)
+		}
+	}
+
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE"
+	}
+
+	if where != "" {
+		where = "WHERE " + where
+	}
+
+	query := fmt.Sprintf(`
+	CREATE %s INDEX IF NOT EXISTS
+		%s
+	ON
+		%s(%s)
+	%s
+	`, unique, index.indexName(g), index.tableName(g), columns, where)
+
+	_, err = db.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return nil
+	}
+
+	properties, err := json.Marshal(index.Properties)
+	if err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`
+	INSERT INTO
+		%s
+		(node_table, edge_table, name, type, properties, is_unique, is_edge)
+	VALUES
+		(?, ?, ?, ?, ?, ?, ?)
+	`, propertyIndexTableName)
+
+	_, err = db.ExecContext(ctx, query, g.nodeTableName, g.edgeTableName, index.Name, index.Type, string(properties), index.Unique, index.Edge)
+
+	return err
+}
+
+// PropertyIndex returns the property index by its name
+func (g *Graph) PropertyIndex(db Executor, name string) (*PropertyIndex, error) {
+	return g.PropertyIndexContext(context.Background(), db, name)
+}
+
+func (g *Graph) PropertyIndexContext(ctx context.Context, db Executor, name string) (*PropertyIndex, error) {
+	indexes, err := g.propertyIndexes(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf(`%w: %s`, ErrPropertyIndexNotFound, name)
+	}
+
+	return &indexes[0], nil
+}
+
+// PropertyIndexes lists the graph's property indexes
+func (g *Graph) PropertyIndexes(db Executor) ([]PropertyIndex, error) {
+	return g.PropertyIndexesContext(context.Background(), db)
+}
+
+func (g *Graph) PropertyIndexesContext(ctx context.Context, db Executor) ([]PropertyIndex, error) {
+	return g.propertyIndexes(ctx, db, "")
+}
+
+func (g *Graph) propertyIndexes(ctx context.Context, db Executor, name string) ([]PropertyIndex, error) {
+	params := []any{g.nodeTableName, g.edgeTableName}
+
+	var byName string
+	if name != "" {
+		byName = "AND name = ?"
+		params = append(params, name)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		name,
+		type,
+		properties,
+		is_unique,
+		is_edge
+	FROM
+		%s
+	WHERE
+		node_table = ?
+	AND
+		edge_table = ?
+	%s
+	ORDER BY
+		name
+	`, propertyIndexTableName, byName)
+
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	indexes := []PropertyIndex{}
+	for rows.Next() {
+		var index PropertyIndex
+		var properties string
+
+		err := rows.Scan(&index.Name, &index.Type, &properties, &index.Unique, &index.Edge)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(properties), &index.Properties)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+// upsertTarget returns the conflict columns and clause of a unique property
+// index on the node or edge table
+func (g *Graph) upsertTarget(ctx context.Context, db Executor, name string, edge bool) (string, string, error) {
+	index, err := g.PropertyIndexContext(ctx, db, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !index.Unique || index.Edge != edge {
+		table := PropertyIndex{Edge: edge}.tableName(g)
+		return "", "", fmt.Errorf(`%w: %s is not a unique index on %s`, ErrBadPropertyIndex, name, table)
+	}
+
+	return index.target()
+}
+
+// propertyIndexTable is the migration that creates the table that stores
+// property index definitions
+func propertyIndexTable(g *Graph) []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			node_table TEXT NOT NULL,
+			edge_table TEXT NOT NULL,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			properties TEXT NOT NULL,
+			is_unique INTEGER NOT NULL,
+			is_edge INTEGER NOT NULL,
+			PRIMARY KEY(node_table, edge_table, name)
+		) strict;`, propertyIndexTableName),
+	}
+}
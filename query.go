@@ -0,0 +1,198 @@
+package pyt
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrKeysetOrderBy   error = errors.New("keyset pagination cannot be combined with order by")
+	ErrBadPropertyPath error = errors.New("bad property path")
+
+	propertyPathKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// OrderBy describes a single order by clause. Either a Field, which is a
+// column name, or a Property, which is a dot separated path into the
+// properties json, should be set
+type OrderBy struct {
+	Field     string
+	Property  string
+	Direction string
+}
+
+// Cursor points at a single record by its time_created and id. It is used
+// for keyset pagination
+type Cursor struct {
+	TimeCreated Time
+	ID          string
+}
+
+// Cursor returns a Cursor pointing at the entity
+func (e entity) Cursor() Cursor {
+	return Cursor{
+		TimeCreated: e.TimeCreated,
+		ID:          e.ID,
+	}
+}
+
+// Keyset pages through records ordered by time_created and then id. Pages
+// are stable, records added after a page was read will not shift the
+// records on the next page
+type Keyset struct {
+	Descending bool
+
+	// After is the last record of the previous page, leave it nil to get
+	// the first page
+	After *Cursor
+}
+
+// QueryOptions controls the ordering and size of a query's result
+type QueryOptions struct {
+	OrderBy []OrderBy
+
+	// Limit caps the number of records returned, zero means there is no limit
+	Limit int
+
+	Offset int
+
+	// Keyset enables keyset pagination and cannot be combined with OrderBy
+	Keyset *Keyset
+
+	// IncludeInactive returns soft deleted records along with the active ones
+	IncludeInactive bool
+}
+
+// activeClause limits a query to active records in each of the aliased tables
+// unless the options ask for inactive records to be included
+func (o *QueryOptions) activeClause(aliases ...string) string {
+	if o != nil && o.IncludeInactive {
+		return ""
+	}
+
+	return activeClause(aliases...)
+}
+
+func activeClause(aliases ...string) string {
+	clauses := make([]string, len(aliases))
+
+	for i, alias := range aliases {
+		if alias != "" {
+			alias = alias + "."
+		}
+
+		clauses[i] = fmt.Sprintf(`%sactive = 1`, alias)
+	}
+
+	return strings.Join(clauses, " AND ")
+}
+
+// Build does the work of converting the options into an additional where
+// clause, for keyset pagination, and the order by, limit, and offset tail
+// of the query. Columns are prefixed with alias when it is not empty
+func (o *QueryOptions) Build(alias string, params *[]any) (string, string, error) {
+	if o == nil {
+		return "", "", nil
+	}
+
+	if alias != "" {
+		alias = alias + "."
+	}
+
+	var where string
+	orderBy := []string{}
+
+	if o.Keyset != nil {
+		if len(o.OrderBy) > 0 {
+			return "", "", ErrKeysetOrderBy
+		}
+
+		direction := "ASC"
+		comparison := ">"
+
+		if o.Keyset.Descending {
+			direction = "DESC"
+			comparison = "<"
+		}
+
+		if o.Keyset.After != nil {
+			where = fmt.Sprintf(`(%[1]stime_created, %[1]sid) %[2]s (?, ?)`, alias, comparison)
+			*params = append(*params, o.Keyset.After.TimeCreated.T.UTC().Format(rfc3339Milli), o.Keyset.After.ID)
+		}
+
+		orderBy = append(orderBy, fmt.Sprintf(`%stime_created %s`, alias, direction), fmt.Sprintf(`%sid %s`, alias, direction))
+	}
+
+	for _, order := range o.OrderBy {
+		direction := strings.ToUpper(strings.TrimSpace(order.Direction))
+		if direction == "" {
+			direction = "ASC"
+		}
+
+		if direction != "ASC" && direction != "DESC" {
+			return "", "", ErrBadOrderByDirection
+		}
+
+		field := alias + order.Field
+		if order.Property != "" {
+			path, err := PropertyPath(order.Property)
+			if err != nil {
+				return "", "", err
+			}
+
+			field = fmt.Sprintf(`json_extract(%sproperties, '%s')`, alias, path)
+		}
+
+		orderBy = append(orderBy, fmt.Sprintf(`%s %s`, field, direction))
+	}
+
+	// the id is added as a tie breaker so that offset pages are stable
+	if len(o.OrderBy) > 0 {
+		orderBy = append(orderBy, fmt.Sprintf(`%sid ASC`, alias))
+	}
+
+	tail := strings.Builder{}
+
+	if len(orderBy) > 0 {
+		tail.WriteString(fmt.Sprintf(`ORDER BY
+		%s`, strings.Join(orderBy, ", ")))
+	}
+
+	if o.Limit > 0 || o.Offset > 0 {
+		limit := o.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+
+		tail.WriteString(`
+	LIMIT ? OFFSET ?`)
+		*params = append(*params, limit, o.Offset)
+	}
+
+	return where, tail.String(), nil
+}
+
+// PropertyPath converts a dot separated property path, "address.city", into
+// a json path, $.address.city. Keys that are not simple identifiers are
+// quoted and keys that contain quotes are rejected
+func PropertyPath(property string) (string, error) {
+	keys := strings.Split(property, ".")
+	path := strings.Builder{}
+	path.WriteString("$")
+
+	for _, key := range keys {
+		if key == "" || strings.ContainsAny(key, `"'`) {
+			return "", fmt.Errorf(`%w: %q`, ErrBadPropertyPath, property)
+		}
+
+		if propertyPathKey.MatchString(key) {
+			path.WriteString("." + key)
+		} else {
+			path.WriteString(fmt.Sprintf(`."%s"`, key))
+		}
+	}
+
+	return path.String(), nil
+}
@@ -0,0 +1,409 @@
+package pyt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrTypeRegistered error = errors.New("type is already registered")
+	ErrBadSchemaTag   error = errors.New("bad pyt struct tag")
+	ErrEdgeEndpoint   error = errors.New("edge endpoints violate the schema")
+	ErrNilRegistry    error = errors.New("types cannot be registered in a nil registry")
+)
+
+// nonIdentifier matches the characters that cannot be used in an index name
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// DefaultRegistry is the registry used by NewNode, NewEdge, and every Graph
+// that is not created WithRegistry
+var DefaultRegistry = NewRegistry()
+
+// Endpoint is a pair of node types that an edge type is allowed to connect.
+// In is the type of the edge's InID node and Out is the type of its OutID node
+type Endpoint struct {
+	In  string
+	Out string
+}
+
+// SchemaProperty is a property of a registered type that is tagged with
+// `pyt:"unique"` or `pyt:"index"`. Its name is taken from the json tag
+type SchemaProperty struct {
+	Name   string
+	Unique bool
+	Index  bool
+}
+
+// NodeSchema is a node type registered with RegisterNode
+type NodeSchema struct {
+	Name       string
+	GoType     reflect.Type
+	Properties []SchemaProperty
+}
+
+// EdgeSchema is an edge type registered with RegisterEdge. An edge type
+// without endpoints can connect any nodes
+type EdgeSchema struct {
+	Name       string
+	GoType     reflect.Type
+	Endpoints  []Endpoint
+	Properties []SchemaProperty
+}
+
+// Registry ties Go types to their node and edge type names. A Go type can
+// only be registered once as a node and once as an edge
+//
+// ex:
+//
+// err := RegisterNode[User](DefaultRegistry, "user")
+// err = RegisterNode[Tweet](DefaultRegistry, "tweet")
+// err = RegisterEdge[Follows](DefaultRegistry, "follows", Endpoint{"user", "user"})
+// err = RegisterEdge[Wrote](DefaultRegistry, "wrote", Endpoint{"user", "tweet"})
+type Registry struct {
+	mu        sync.RWMutex
+	nodes     map[string]*NodeSchema
+	edges     map[string]*EdgeSchema
+	nodeTypes map[reflect.Type]string
+	edgeTypes map[reflect.Type]string
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		nodes:     map[string]*NodeSchema{},
+		edges:     map[string]*EdgeSchema{},
+		nodeTypes: map[reflect.Type]string{},
+		edgeTypes: map[reflect.Type]string{},
+	}
+}
+
+// RegisterNode registers T as the properties of the name node type
+func RegisterNode[T any](r *Registry, name string) error {
+	if r == nil {
+		return ErrNilRegistry
+	}
+
+	goType := typeOf[T]()
+	properties, err := schemaProperties(goType)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.nodes[name]; ok {
+		return fmt.Errorf(`%w: node %s`, ErrTypeRegistered, name)
+	}
+
+	if existing, ok := r.nodeTypes[goType]; ok {
+		return fmt.Errorf(`%w: %s is the node %s`, ErrTypeRegistered, goType, existing)
+	}
+
+	r.nodes[name] = &NodeSchema{
+		Name:       name,
+		GoType:     goType,
+		Properties: properties,
+	}
+	r.nodeTypes[goType] = name
+
+	return nil
+}
+
+// RegisterEdge registers T as the properties of the name edge type. When
+// endpoints are provided, edges of the type can only connect those node types
+func RegisterEdge[T any](r *Registry, name string, endpoints ...Endpoint) error {
+	if r == nil {
+		return ErrNilRegistry
+	}
+
+	goType := typeOf[T]()
+	properties, err := schemaProperties(goType)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.edges[name]; ok {
+		return fmt.Errorf(`%w: edge %s`, ErrTypeRegistered, name)
+	}
+
+	if existing, ok := r.edgeTypes[goType]; ok {
+		return fmt.Errorf(`%w: %s is the edge %s`, ErrTypeRegistered, goType, existing)
+	}
+
+	r.edges[name] = &EdgeSchema{
+		Name:       name,
+		GoType:     goType,
+		Endpoints:  endpoints,
+		Properties: properties,
+	}
+	r.edgeTypes[goType] = name
+
+	return nil
+}
+
+// NodeTypeOf returns the node type name that T is registered as
+func NodeTypeOf[T any](r *Registry) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	name, ok := r.nodeTypes[typeOf[T]()]
+
+	return name, ok
+}
+
+// EdgeTypeOf returns the edge type name that T is registered as
+func EdgeTypeOf[T any](r *Registry) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	name, ok := r.edgeTypes[typeOf[T]()]
+
+	return name, ok
+}
+
+// NodeSchema returns the registered node type
+func (r *Registry) NodeSchema(name string) (*NodeSchema, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.nodes[name]
+
+	return schema, ok
+}
+
+// EdgeSchema returns the registered edge type
+func (r *Registry) EdgeSchema(name string) (*EdgeSchema, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.edges[name]
+
+	return schema, ok
+}
+
+// PropertyIndexes returns a PropertyIndex for every tagged property of the
+// registered types. They are named <type>_<property>_idx and are created
+// by BuildSchema
+func (r *Registry) PropertyIndexes() []PropertyIndex {
+	if r == nil {
+		return []PropertyIndex{}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	indexes := []PropertyIndex{}
+	add := func(typeName string, properties []SchemaProperty, edge bool) {
+		for _, property := range properties {
+			indexes = append(indexes, PropertyIndex{
+				Name:       nonIdentifier.ReplaceAllString(fmt.Sprintf(`%s_%s_idx`, typeName, property.Name), "_"),
+				Type:       typeName,
+				Properties: []string{property.Name},
+				Unique:     property.Unique,
+				Edge:       edge,
+			})
+		}
+	}
+
+	for name, schema := range r.nodes {
+		add(name, schema.Properties, false)
+	}
+
+	for name, schema := range r.edges {
+		add(name, schema.Properties, true)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].Name < indexes[j].Name
+	})
+
+	return indexes
+}
+
+// checkEndpoint returns an error when the edge type is registered with
+// endpoints and none of them match the in and out node types
+func (r *Registry) checkEndpoint(edgeType, inType, outType string) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.edges[edgeType]
+	if !ok || len(schema.Endpoints) == 0 {
+		return nil
+	}
+
+	for _, endpoint := range schema.Endpoints {
+		if endpoint.In == inType && endpoint.Out == outType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(`%w: %s cannot connect %q to %q`, ErrEdgeEndpoint, edgeType, inType, outType)
+}
+
+// hasEndpoints reports if any of the edge types are registered with endpoints
+func (r *Registry) hasEndpoints(edgeTypes ...string) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, edgeType := range edgeTypes {
+		if schema, ok := r.edges[edgeType]; ok && len(schema.Endpoints) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// typeOf returns the reflect.Type of T, even when T is an interface
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// schemaProperties reads the pyt tags from the fields of a struct type
+func schemaProperties(goType reflect.Type) ([]SchemaProperty, error) {
+	properties := []SchemaProperty{}
+
+	if goType.Kind() != reflect.Struct {
+		return properties, nil
+	}
+
+	for i := 0; i < goType.NumField(); i++ {
+		field := goType.Field(i)
+		tag, ok := field.Tag.Lookup("pyt")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ","); jsonName != "" {
+			name = jsonName
+		}
+
+		if name == "-" {
+			return nil, fmt.Errorf(`%w: %s is not stored`, ErrBadSchemaTag, field.Name)
+		}
+
+		property := SchemaProperty{
+			Name: name,
+		}
+
+		for _, option := range strings.Split(tag, ",") {
+			switch strings.TrimSpace(option) {
+			case "unique":
+				property.Unique = true
+				property.Index = true
+			case "index":
+				property.Index = true
+			default:
+				return nil, fmt.Errorf(`%w: %q on %s`, ErrBadSchemaTag, option, field.Name)
+			}
+		}
+
+		properties = append(properties, property)
+	}
+
+	return properties, nil
+}
+
+// edgeEnds is the part of an edge that is checked against the registry
+type edgeEnds struct {
+	edgeType string
+	inID     string
+	outID    string
+}
+
+// checkEdgeEndpoints looks up the type of each edge's nodes and returns an
+// error if an edge breaks the endpoints registered for its type
+func (g *Graph) checkEdgeEndpoints(ctx context.Context, db Executor, edges []edgeEnds) error {
+	types := make([]string, len(edges))
+	for i, edge := range edges {
+		types[i] = edge.edgeType
+	}
+
+	if !g.registry.hasEndpoints(types...) {
+		return nil
+	}
+
+	holders := []string{}
+	params := []any{}
+	for _, edge := range edges {
+		holders = append(holders, "?", "?")
+		params = append(params, edge.inID, edge.outID)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		id,
+		type
+	FROM
+		%s
+	WHERE
+		id IN (%s)
+	`, g.nodeTableName, strings.Join(holders, ", "))
+
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	nodeTypes := map[string]string{}
+	for rows.Next() {
+		var id, nodeType string
+
+		err := rows.Scan(&id, &nodeType)
+		if err != nil {
+			return err
+		}
+
+		nodeTypes[id] = nodeType
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, edge := range edges {
+		err := g.registry.checkEndpoint(edge.edgeType, nodeTypes[edge.inID], nodeTypes[edge.outID])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package pyt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// setActiveByIDs flips the active flag for the ids in tableName and
+// returns the number of records that were changed
+func setActiveByIDs(ctx context.Context, db Executor, tableName string, active bool, ids ...string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	holders, idParams := placeholders(ids)
+	params := append([]any{active, active}, idParams...)
+
+	query := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		active = ?
+	WHERE
+		active != ?
+	AND
+		id IN (%s)
+	`, tableName, holders)
+
+	res, err := db.ExecContext(ctx, query, params...)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// NodeSoftDelete marks the nodes as inactive. Inactive nodes are skipped by
+// all of the read functions unless they are asked to include them
+func NodeSoftDelete(db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeSoftDelete(db, nodeIDs...)
+}
+
+func NodeSoftDeleteContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeSoftDeleteContext(ctx, db, nodeIDs...)
+}
+
+func NodeSoftDeleteWithTableName(db Executor, nodeTableName string, nodeIDs ...string) (int64, error) {
+	return tableGraph(nodeTableName, DefaultEdgeTableName).NodeSoftDelete(db, nodeIDs...)
+}
+
+// NodeSoftDelete marks the nodes as inactive. Inactive nodes are skipped by
+// all of the read functions unless they are asked to include them
+func (g *Graph) NodeSoftDelete(db Executor, nodeIDs ...string) (int64, error) {
+	return g.NodeSoftDeleteContext(context.Background(), db, nodeIDs...)
+}
+
+func (g *Graph) NodeSoftDeleteContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	return setActiveByIDs(ctx, db, g.nodeTableName, false, nodeIDs...)
+}
+
+// NodeRestore marks soft deleted nodes as active
+func NodeRestore(db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeRestore(db, nodeIDs...)
+}
+
+func NodeRestoreContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeRestoreContext(ctx, db, nodeIDs...)
+}
+
+func NodeRestoreWithTableName(db Executor, nodeTableName string, nodeIDs ...string) (int64, error) {
+	return tableGraph(nodeTableName, DefaultEdgeTableName).NodeRestore(db, nodeIDs...)
+}
+
+// NodeRestore marks soft deleted nodes as active
+func (g *Graph) NodeRestore(db Executor, nodeIDs ...string) (int64, error) {
+	return g.NodeRestoreContext(context.Background(), db, nodeIDs...)
+}
+
+func (g *Graph) NodeRestoreContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	return setActiveByIDs(ctx, db, g.nodeTableName, true, nodeIDs...)
+}
+
+// EdgeSoftDelete marks the edges as inactive. Inactive edges are skipped by
+// all of the read functions unless they are asked to include them
+func EdgeSoftDelete(db Executor, edgeIDs ...string) (int64, error) {
+	return defaultGraph().EdgeSoftDelete(db, edgeIDs...)
+}
+
+func EdgeSoftDeleteContext(ctx context.Context, db Executor, edgeIDs ...string) (int64, error) {
+	return defaultGraph().EdgeSoftDeleteContext(ctx, db, edgeIDs...)
+}
+
+func EdgeSoftDeleteWithTableName(db Executor, edgeTableName string, edgeIDs ...string) (int64, error) {
+	return tableGraph(DefaultNodeTableName, edgeTableName).EdgeSoftDelete(db, edgeIDs...)
+}
+
+// EdgeSoftDelete marks the edges as inactive. Inactive edges are skipped by
+// all of the read functions unless they are asked to include them
+func (g *Graph) EdgeSoftDelete(db Executor, edgeIDs ...string) (int64, error) {
+	return g.EdgeSoftDeleteContext(context.Background(), db, edgeIDs...)
+}
+
+func (g *Graph) EdgeSoftDeleteContext(ctx context.Context, db Executor, edgeIDs ...string) (int64, error) {
+	return setActiveByIDs(ctx, db, g.edgeTableName, false, edgeIDs...)
+}
+
+// EdgeRestore marks soft deleted edges as active
+func EdgeRestore(db Executor, edgeIDs ...string) (int64, error) {
+	return defaultGraph().EdgeRestore(db, edgeIDs...)
+}
+
+func EdgeRestoreContext(ctx context.Context, db Executor, edgeIDs ...string) (int64, error) {
+	return defaultGraph().EdgeRestoreContext(ctx, db, edgeIDs...)
+}
+
+func EdgeRestoreWithTableName(db Executor, edgeTableName string, edgeIDs ...string) (int64, error) {
+	return tableGraph(DefaultNodeTableName, edgeTableName).EdgeRestore(db, edgeIDs...)
+}
+
+// EdgeRestore marks soft deleted edges as active
+func (g *Graph) EdgeRestore(db Executor, edgeIDs ...string) (int64, error) {
+	return g.EdgeRestoreContext(context.Background(), db, edgeIDs...)
+}
+
+func (g *Graph) EdgeRestoreContext(ctx context.Context, db Executor, edgeIDs ...string) (int64, error) {
+	return setActiveByIDs(ctx, db, g.edgeTableName, true, edgeIDs...)
+}
+
+// softDeleteTableName is the table that records which edges were deactivated
+// when a node was soft deleted with NodeSoftDeleteCascade
+func softDeleteTableName(edgeTableName string) string {
+	return edgeTableName + "_soft_delete"
+}
+
+// placeholders creates a ?, ? list for the ids along with their params
+func placeholders(ids []string) (string, []any) {
+	params := make([]any, len(ids))
+	holders := make([]string, len(ids))
+
+	for i, id := range ids {
+		holders[i] = "?"
+		params[i] = id
+	}
+
+	return strings.Join(holders, ", "), params
+}
+
+// NodeSoftDeleteCascade marks the nodes and the edges that touch them as
+// inactive. See Graph.NodeSoftDeleteCascade
+func NodeSoftDeleteCascade(db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeSoftDeleteCascade(db, nodeIDs...)
+}
+
+func NodeSoftDeleteCascadeContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeSoftDeleteCascadeContext(ctx, db, nodeIDs...)
+}
+
+func NodeSoftDeleteCascadeWithTableName(db Executor, nodeTableName, edgeTableName string, nodeIDs ...string) (int64, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodeSoftDeleteCascade(db, nodeIDs...)
+}
+
+// NodeSoftDeleteCascade marks the nodes as inactive along with every active
+// edge that touches them. The deactivated edges are recorded so that
+// NodeRestoreCascade can reactivate exactly those edges, edges that were
+// soft deleted on their own are left alone. The number of nodes that were
+// deactivated is returned. The statements run in a single transaction, one
+// is started when db is not already a *sql.Tx
+func (g *Graph) NodeSoftDeleteCascade(db Executor, nodeIDs ...string) (int64, error) {
+	return g.NodeSoftDeleteCascadeContext(context.Background(), db, nodeIDs...)
+}
+
+func (g *Graph) NodeSoftDeleteCascadeContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	if len(nodeIDs) == 0 {
+		return 0, nil
+	}
+
+	holders, params := placeholders(nodeIDs)
+	queries := []string{
+		fmt.Sprintf(`
+		INSERT OR IGNORE INTO
+			%[1]s
+			(node_id, edge_id)
+		SELECT
+			n.id,
+			e.id
+		FROM
+			%[2]s e
+		JOIN
+			%[3]s n ON n.id IN (e.in_id, e.out_id)
+		WHERE
+			(e.active = 1 OR e.id IN (SELECT edge_id FROM %[1]s))
+		AND
+			n.id IN (%[4]s)
+		`, softDeleteTableName(g.edgeTableName), g.edgeTableName, g.nodeTableName, holders),
+
+		fmt.Sprintf(`
+		UPDATE
+			%[1]s
+		SET
+			active = 0
+		WHERE
+			active = 1
+		AND
+			id IN (SELECT edge_id FROM %[2]s WHERE node_id IN (%[3]s))
+		`, g.edgeTableName, softDeleteTableName(g.edgeTableName), holders),
+	}
+
+	var count int64
+	err := inTx(ctx, db, func(db Executor) error {
+		for _, query := range queries {
+			_, err := db.ExecContext(ctx, query, params...)
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		count, err = setActiveByIDs(ctx, db, g.nodeTableName, false, nodeIDs...)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// NodeRestoreCascade marks the nodes and the edges that were cascaded with
+// them as active. See Graph.NodeRestoreCascade
+func NodeRestoreCascade(db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeRestoreCascade(db, nodeIDs...)
+}
+
+func NodeRestoreCascadeContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	return defaultGraph().NodeRestoreCascadeContext(ctx, db, nodeIDs...)
+}
+
+func NodeRestoreCascadeWithTableName(db Executor, nodeTableName, edgeTableName string, nodeIDs ...string) (int64, error) {
+	return tableGraph(nodeTableName, edgeTableName).NodeRestoreCascade(db, nodeIDs...)
+}
+
+// NodeRestoreCascade marks the nodes as active along with the edges that
+// were deactivated when they were soft deleted with NodeSoftDeleteCascade.
+// An edge between two cascaded nodes stays inactive until both nodes are
+// restored. The number of nodes that were restored is returned. The
+// statements run in a single transaction, one is started when db is not
+// already a *sql.Tx
+func (g *Graph) NodeRestoreCascade(db Executor, nodeIDs ...string) (int64, error) {
+	return g.NodeRestoreCascadeContext(context.Background(), db, nodeIDs...)
+}
+
+func (g *Graph) NodeRestoreCascadeContext(ctx context.Context, db Executor, nodeIDs ...string) (int64, error) {
+	if len(nodeIDs) == 0 {
+		return 0, nil
+	}
+
+	holders, params := placeholders(nodeIDs)
+
+	queries := []struct {
+		query  string
+		params []any
+	}{
+		{
+			query: fmt.Sprintf(`
+			UPDATE
+				%[1]s
+			SET
+				active = 1
+			WHERE
+				id IN (SELECT edge_id FROM %[2]s WHERE node_id IN (%[3]s))
+			AND
+				id NOT IN (SELECT edge_id FROM %[2]s WHERE node_id NOT IN (%[3]s))
+			`, g.edgeTableName, softDeleteTableName(g.edgeTableName), holders),
+			params: append(params, params...),
+		},
+		{
+			query: fmt.Sprintf(`
+			DELETE FROM
+				%s
+			WHERE
+				node_id IN (%s)
+			`, softDeleteTableName(g.edgeTableName), holders),
+			params: params,
+		},
+	}
+
+	var count int64
+	err := inTx(ctx, db, func(db Executor) error {
+		for _, query := range queries {
+			_, err := db.ExecContext(ctx, query.query, query.params...)
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		count, err = setActiveByIDs(ctx, db, g.nodeTableName, true, nodeIDs...)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,267 @@
+package pyt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrEmptyTraversal      error = errors.New("traversal needs at least one start node and one hop")
+	ErrBadOrderByDirection error = errors.New("order by direction must be asc or desc")
+)
+
+type hop struct {
+	direction string
+	edgeType  string
+}
+
+// Traversal is a fluent builder that walks multiple hops from a set of
+// start nodes and compiles the walk into a single joined query.
+//
+// Every hop joins the edge and node tables again. The first hop's tables are
+// aliased as e1 and n1, the second as e2 and n2, and so on. Filters and
+// ordering can reference any of those aliases
+//
+// ex:
+// the tweets written by the users that a user follows
+//
+// NewTraversal(userID).
+// Out("follows").
+// Out("wrote").
+// OrderBy("n2.time_created", "desc").
+// Limit(20)
+type Traversal struct {
+	nodeTableName string
+	edgeTableName string
+	nodeIDs       []string
+	hops          []hop
+	registry      *Registry
+	filters       FilterSet
+	orderBy       []OrderBy
+	limit         int
+	inactive      bool
+	err           error
+}
+
+// NewTraversal creates a Traversal starting at the provided node ids
+func NewTraversal(nodeIDs ...string) *Traversal {
+	return defaultGraph().NewTraversal(nodeIDs...)
+}
+
+func NewTraversalWithTableName(nodeTableName, edgeTableName string, nodeIDs ...string) *Traversal {
+	return tableGraph(nodeTableName, edgeTableName).NewTraversal(nodeIDs...)
+}
+
+// NewTraversal creates a Traversal over the graph's tables starting at the
+// provided node ids
+func (g *Graph) NewTraversal(nodeIDs ...string) *Traversal {
+	return &Traversal{
+		nodeTableName: g.nodeTableName,
+		edgeTableName: g.edgeTableName,
+		registry:      g.registry,
+		nodeIDs:       nodeIDs,
+	}
+}
+
+// Out adds an out hop via the edgeType
+func (t *Traversal) Out(edgeType string) *Traversal {
+	t.hops = append(t.hops, hop{
+		direction: "out",
+		edgeType:  edgeType,
+	})
+
+	return t
+}
+
+// In adds an in hop via the edgeType
+func (t *Traversal) In(edgeType string) *Traversal {
+	t.hops = append(t.hops, hop{
+		direction: "in",
+		edgeType:  edgeType,
+	})
+
+	return t
+}
+
+// Filter extends the traversal's where clause. The filters can reference
+// any of the hop aliases (e1, n1, e2, n2...) and are checked against the
+// allow list when the traversal is built, see FilterSet.Validate
+func (t *Traversal) Filter(filters FilterSet) *Traversal {
+	t.filters = append(t.filters, filters...)
+
+	return t
+}
+
+// OrderBy adds an order by clause to the traversal. The field can be a
+// column or a Prop of any of the hop aliases and the direction must be
+// either asc or desc
+func (t *Traversal) OrderBy(field, direction string) *Traversal {
+	direction = strings.ToUpper(strings.TrimSpace(direction))
+	if direction != "ASC" && direction != "DESC" {
+		t.err = errors.Join(t.err, ErrBadOrderByDirection)
+		return t
+	}
+
+	t.orderBy = append(t.orderBy, OrderBy{
+		Field:     field,
+		Direction: direction,
+	})
+
+	return t
+}
+
+// Limit caps the number of records returned by the traversal. Zero means
+// there is no limit
+func (t *Traversal) Limit(limit int) *Traversal {
+	t.limit = limit
+
+	return t
+}
+
+// IncludeInactive will walk soft deleted edges and nodes. By default only
+// active edges and nodes are walked
+func (t *Traversal) IncludeInactive() *Traversal {
+	t.inactive = true
+
+	return t
+}
+
+// Build compiles the traversal into a query and the params that should
+// be bound to it. The selected columns are the edge and node of the last hop
+func (t *Traversal) Build() (string, []any, error) {
+	if t.err != nil {
+		return "", nil, t.err
+	}
+
+	if len(t.nodeIDs) == 0 || len(t.hops) == 0 {
+		return "", nil, ErrEmptyTraversal
+	}
+
+	aliases := []string{}
+	for i := range t.hops {
+		aliases = append(aliases, fmt.Sprintf("e%d", i+1), fmt.Sprintf("n%d", i+1))
+	}
+
+	rules := newFieldRules(t.registry, aliases...)
+
+	err := rules.filters(t.filters...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	orderBy := []string{}
+	for _, order := range t.orderBy {
+		err := rules.field(order.Field)
+		if err != nil {
+			return "", nil, err
+		}
+
+		orderBy = append(orderBy, fmt.Sprintf(`%s %s`, order.Field, order.Direction))
+	}
+
+	params := []any{}
+	holders := make([]string, len(t.nodeIDs))
+
+	for i, id := range t.nodeIDs {
+		holders[i] = "?"
+		params = append(params, id)
+	}
+
+	joins := []string{}
+	wheres := []string{}
+
+	for i, h := range t.hops {
+		edgeAlias := fmt.Sprintf("e%d", i+1)
+		nodeAlias := fmt.Sprintf("n%d", i+1)
+		edgeWhere := "in_id"
+		edgeJoin := "out_id"
+
+		if h.direction == "in" {
+			edgeJoin = "in_id"
+			edgeWhere = "out_id"
+		}
+
+		if i == 0 {
+			wheres = append(wheres, fmt.Sprintf(`%s.%s IN (%s)`, edgeAlias, edgeWhere, strings.Join(holders, ", ")))
+		} else {
+			joins = append(joins, fmt.Sprintf(`JOIN
+		%s %s ON %s.%s = n%d.id`, t.edgeTableName, edgeAlias, edgeAlias, edgeWhere, i))
+		}
+
+		joins = append(joins, fmt.Sprintf(`JOIN
+		%s %s ON %s.id = %s.%s`, t.nodeTableName, nodeAlias, nodeAlias, edgeAlias, edgeJoin))
+		wheres = append(wheres, fmt.Sprintf(`%s.type = ?`, edgeAlias))
+		params = append(params, h.edgeType)
+
+		if !t.inactive {
+			wheres = append(wheres, activeClause(edgeAlias, nodeAlias))
+		}
+	}
+
+	if clauses := t.filters.Build(&params); clauses != "" {
+		wheres = append(wheres, clauses)
+	}
+
+	var orderByClause string
+	if len(orderBy) > 0 {
+		orderByClause = fmt.Sprintf(`ORDER BY
+		%s`, strings.Join(orderBy, ", "))
+	}
+
+	var limit string
+	if t.limit > 0 {
+		limit = `LIMIT ?`
+		params = append(params, t.limit)
+	}
+
+	last := len(t.hops)
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s e1
+	%s
+	WHERE
+		%s
+	%s
+	%s
+	`, edgeNodeColumns(fmt.Sprintf("e%d", last), fmt.Sprintf("n%d", last)), t.edgeTableName, strings.Join(joins, "\n\t"), strings.Join(wheres, "\n\tAND\n\t\t"), orderByClause, limit)
+
+	return query, params, nil
+}
+
+// Traverse runs the traversal and returns the edge and node of the last hop
+// as a typed TypedNodeEdgeSet
+func Traverse[NodeType any, EdgeType any](db Executor, t *Traversal) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	return TraverseContext[NodeType, EdgeType](context.Background(), db, t)
+}
+
+func TraverseContext[NodeType any, EdgeType any](ctx context.Context, db Executor, t *Traversal) (*TypedNodeEdgeSet[NodeType, EdgeType], error) {
+	query, params, err := t.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	set, err := RowsToNodeEdge[NodeType, EdgeType](rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
@@ -0,0 +1,69 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// WithTx runs fn in a transaction and owns its outcome. The transaction is
+// committed when fn returns nil and rolled back when fn returns an error or
+// panics. None of the functions in this package roll back the transaction
+// that they are given, an expected error like sql.ErrNoRows can be handled
+// inside of fn without losing the work that came before it
+//
+// ex:
+//
+//	err := WithTx(ctx, db, func(tx *sql.Tx) error {
+//		_, err := NodeGetByID[User](tx, userID)
+//		if errors.Is(err, sql.ErrNoRows) {
+//			_, err = NodeCreate(tx, *NewNode(userID, "user", User{Username: "mark"}))
+//		}
+//
+//		return err
+//	})
+func WithTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	return withTx(ctx, db, fn)
+}
+
+// txBeginner is an Executor that can start a transaction, *sql.DB and
+// *sql.Conn are both txBeginners
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+func withTx(ctx context.Context, db txBeginner, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	return tx.Commit()
+}
+
+// inTx runs the statements of a multi statement write in a single
+// transaction. When db can begin a transaction one is started and owned by
+// inTx, otherwise db is already a transaction and the statements become
+// part of it
+func inTx(ctx context.Context, db Executor, fn func(db Executor) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+
+	return withTx(ctx, beginner, func(tx *sql.Tx) error {
+		return fn(tx)
+	})
+}
@@ -0,0 +1,378 @@
+package pyt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadEdgeUniqueness error = errors.New("bad edge uniqueness")
+	ErrBadEdgeConflict   error = errors.New("bad edge conflict")
+)
+
+// EdgeUniqueness controls how many edges of the same type can connect the
+// same two nodes
+type EdgeUniqueness int
+
+const (
+	// EdgeUniqueProperties allows a single edge of a type between two nodes
+	// for each distinct set of properties. This is the default
+	EdgeUniqueProperties EdgeUniqueness = iota
+
+	// EdgeUniqueType allows a single edge of a type between two nodes
+	EdgeUniqueType
+
+	// EdgeMulti allows any number of edges of a type between two nodes
+	EdgeMulti
+)
+
+// EdgeConflict is what happens when a new edge breaks the EdgeUniqueness.
+// Only the uniqueness is resolved, a new edge that reuses an existing id
+// always returns an error
+type EdgeConflict string
+
+const (
+	// EdgeConflictReplace updates the existing edge with the new edge's
+	// active flag and properties, the existing edge keeps its id. This is
+	// the default
+	EdgeConflictReplace EdgeConflict = "REPLACE"
+
+	// EdgeConflictIgnore keeps the existing edge and skips the new one.
+	// Skipped edges are not returned by EdgesCreate
+	EdgeConflictIgnore EdgeConflict = "IGNORE"
+
+	// EdgeConflictError returns the unique constraint error from sqlite
+	EdgeConflictError EdgeConflict = "ABORT"
+)
+
+// edgePolicyTableName stores the edge uniqueness policy of each graph so
+// that every graph on the same tables inserts edges the same way
+const edgePolicyTableName string = "pyt_edge_policy"
+
+// edgePolicy is the uniqueness and conflict of a graph's edge table
+type edgePolicy struct {
+	uniqueness EdgeUniqueness
+	conflict   EdgeConflict
+}
+
+// defaultEdgePolicy is used by graphs that do not set a policy on tables
+// that do not have one stored
+var defaultEdgePolicy = edgePolicy{
+	uniqueness: EdgeUniqueProperties,
+	conflict:   EdgeConflictReplace,
+}
+
+// WithEdgeUniqueness sets the graph's edge uniqueness policy. BuildSchema
+// creates, or swaps out, the unique index and stores the policy with the
+// schema. Graphs that do not set a policy use the stored one, including
+// the package level functions
+func WithEdgeUniqueness(uniqueness EdgeUniqueness, conflict EdgeConflict) GraphOption {
+	return func(g *Graph) {
+		g.edgePolicy = &edgePolicy{
+			uniqueness: uniqueness,
+			conflict:   conflict,
+		}
+	}
+}
+
+// edgeUniqueIndexes maps each policy to the name and columns of the unique
+// index that enforces it
+func (g *Graph) edgeUniqueIndexes() map[EdgeUniqueness]index {
+	return map[EdgeUniqueness]index{
+		EdgeUniqueProperties: {name: g.edgeTableName + "_unique_properties_idx", table: g.edgeTableName, columns: "type, in_id, out_id, properties"},
+		EdgeUniqueType:       {name: g.edgeTableName + "_unique_type_idx", table: g.edgeTableName, columns: "type, in_id, out_id"},
+	}
+}
+
+// edgeUniqueIndex returns the unique index for the policy, none is returned
+// for EdgeMulti
+func (g *Graph) edgeUniqueIndex(policy edgePolicy) ([]index, error) {
+	if policy.uniqueness == EdgeMulti {
+		return nil, nil
+	}
+
+	idx, ok := g.edgeUniqueIndexes()[policy.uniqueness]
+	if !ok {
+		return nil, fmt.Errorf(`%w: %d`, ErrBadEdgeUniqueness, policy.uniqueness)
+	}
+
+	return []index{idx}, nil
+}
+
+// edgeConflictClause returns the upsert clause that resolves a new edge
+// that breaks the policy. The conflict target is the policy's unique index
+// so that any other constraint, like the primary key, still fails
+func (g *Graph) edgeConflictClause(policy edgePolicy) (string, error) {
+	unique, err := g.edgeUniqueIndex(policy)
+	if err != nil {
+		return "", err
+	}
+
+	switch policy.conflict {
+	case EdgeConflictError:
+		return "", nil
+	case EdgeConflictReplace, EdgeConflictIgnore:
+	default:
+		return "", fmt.Errorf(`%w: %q`, ErrBadEdgeConflict, policy.conflict)
+	}
+
+	if len(unique) == 0 {
+		return "", nil
+	}
+
+	if policy.conflict == EdgeConflictIgnore {
+		return fmt.Sprintf(`ON CONFLICT (%s) DO NOTHING`, unique[0].columns), nil
+	}
+
+	return fmt.Sprintf(`ON CONFLICT (%s) DO UPDATE SET
+		active = excluded.active,
+		properties = excluded.properties`, unique[0].columns), nil
+}
+
+// resolveEdgePolicy returns the graph's policy, or the one stored with the
+// schema when the graph does not set one
+func (g *Graph) resolveEdgePolicy(ctx context.Context, db Executor) (edgePolicy, error) {
+	if g.edgePolicy != nil {
+		return *g.edgePolicy, nil
+	}
+
+	stored, err := g.storedEdgePolicy(ctx, db)
+	if err != nil {
+		return edgePolicy{}, err
+	}
+
+	if stored == nil {
+		return defaultEdgePolicy, nil
+	}
+
+	return *stored, nil
+}
+
+// storedEdgePolicy returns the policy stored with the schema, nil is
+// returned when there is none
+func (g *Graph) storedEdgePolicy(ctx context.Context, db Executor) (*edgePolicy, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, `
+	SELECT
+		COUNT(*) > 0
+	FROM
+		sqlite_master
+	WHERE
+		type = 'table'
+	AND
+		name = ?
+	`, edgePolicyTableName).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		uniqueness,
+		conflict
+	FROM
+		%s
+	WHERE
+		node_table = ?
+	AND
+		edge_table = ?
+	`, edgePolicyTableName)
+
+	policy := edgePolicy{}
+	err = db.QueryRowContext(ctx, query, g.nodeTableName, g.edgeTableName).Scan(&policy.uniqueness, &policy.conflict)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// storeEdgePolicy saves the policy with the schema
+func (g *Graph) storeEdgePolicy(ctx context.Context, tx *sql.Tx, policy edgePolicy) error {
+	query := fmt.Sprintf(`
+	INSERT INTO
+		%s
+		(node_table, edge_table, uniqueness, conflict)
+	VALUES
+		(?, ?, ?, ?)
+	ON CONFLICT (node_table, edge_table) DO UPDATE SET
+		uniqueness = excluded.uniqueness,
+		conflict = excluded.conflict
+	`, edgePolicyTableName)
+
+	_, err := tx.ExecContext(ctx, query, g.nodeTableName, g.edgeTableName, policy.uniqueness, policy.conflict)
+
+	return err
+}
+
+// applyEdgeUniqueness makes sure that the unique index for the graph's
+// policy is the only one on the edge table and stores the policy. Creating
+// the index fails when the existing edges break the policy
+func (g *Graph) applyEdgeUniqueness(ctx context.Context, tx *sql.Tx) error {
+	policy, err := g.resolveEdgePolicy(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.edgeConflictClause(policy)
+	if err != nil {
+		return err
+	}
+
+	keep, err := g.edgeUniqueIndex(policy)
+	if err != nil {
+		return err
+	}
+
+	for uniqueness, idx := range g.edgeUniqueIndexes() {
+		if uniqueness == policy.uniqueness {
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP INDEX IF EXISTS %s;`, idx.name))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, idx := range keep {
+		query := fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s(%s);`, idx.name, idx.table, idx.columns)
+
+		_, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	return g.storeEdgePolicy(ctx, tx, policy)
+}
+
+// edgePolicyTable is the migration that creates the table that stores the
+// edge uniqueness policy. Before the policy was stored it only lived on the
+// graph, so it is recovered from the unique index that the graph left behind
+func edgePolicyTable(ctx context.Context, tx *sql.Tx, g *Graph) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		node_table TEXT NOT NULL,
+		edge_table TEXT NOT NULL,
+		uniqueness INTEGER NOT NULL,
+		conflict TEXT NOT NULL,
+		PRIMARY KEY(node_table, edge_table)
+	) strict;`, edgePolicyTableName)
+
+	_, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	for uniqueness, idx := range g.edgeUniqueIndexes() {
+		var exists bool
+		err := tx.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) > 0
+		FROM
+			sqlite_master
+		WHERE
+			type = 'index'
+		AND
+			name = ?
+		`, idx.name).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return g.storeEdgePolicy(ctx, tx, edgePolicy{uniqueness, EdgeConflictReplace})
+		}
+	}
+
+	// edgeTableWithoutUnique leaves the EdgeUniqueProperties index behind,
+	// so edges without a unique index were created under EdgeMulti. A new
+	// graph has no edges and takes its policy from BuildSchema
+	var hasEdges bool
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s)`, g.edgeTableName)).Scan(&hasEdges)
+	if err != nil {
+		return err
+	}
+
+	if hasEdges {
+		return g.storeEdgePolicy(ctx, tx, edgePolicy{EdgeMulti, EdgeConflictReplace})
+	}
+
+	return nil
+}
+
+// edgeTableWithoutUnique is the migration that moves edge uniqueness out of
+// the edge table's UNIQUE(in_id, out_id, properties) constraint, which
+// ignored the edge type, and into the unique index managed by
+// applyEdgeUniqueness. sqlite cannot drop a constraint so the table is
+// rebuilt and given the EdgeUniqueProperties index in place of the constraint
+func edgeTableWithoutUnique(ctx context.Context, tx *sql.Tx, g *Graph) error {
+	newTableName := g.edgeTableName + "_rebuild"
+
+	queries := []string{
+		fmt.Sprintf(`CREATE TABLE %[1]s (
+			id TEXT NOT NULL UNIQUE PRIMARY KEY,
+			active INTEGER DEFAULT 1,
+			type TEXT NOT NULL,
+			in_id TEXT,
+			out_id TEXT,
+			properties TEXT,
+			time_created TEXT NOT NULL DEFAULT (strftime(%[3]s)),
+			time_updated TEXT NOT NULL DEFAULT (strftime(%[3]s)),
+			FOREIGN KEY(in_id) REFERENCES %[2]s(id) ON DELETE CASCADE,
+			FOREIGN KEY(out_id) REFERENCES %[2]s(id) ON DELETE CASCADE
+		) strict;`, newTableName, g.nodeTableName, timeFormat),
+
+		fmt.Sprintf(`INSERT INTO
+			%s
+			(id, active, type, in_id, out_id, properties, time_created, time_updated)
+		SELECT
+			id, active, type, in_id, out_id, properties, time_created, time_updated
+		FROM
+			%s;`, newTableName, g.edgeTableName),
+
+		fmt.Sprintf(`DROP TABLE %s;`, g.edgeTableName),
+
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s;`, newTableName, g.edgeTableName),
+
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_time_updated_trigger
+		AFTER UPDATE ON %[1]s
+		BEGIN
+			UPDATE
+				%[1]s
+			SET
+				time_updated = STRFTIME(%[2]s, 'NOW')
+			WHERE id = NEW.id;
+		END;`, g.edgeTableName, timeFormat),
+	}
+
+	for _, query := range queries {
+		_, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := g.createIndexes(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	// the unique constraint is replaced with the index of the policy that
+	// it enforced so that the edges keep being deduplicated until
+	// applyEdgeUniqueness runs and edgePolicyTable can store the policy
+	idx := g.edgeUniqueIndexes()[EdgeUniqueProperties]
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s(%s);`, idx.name, idx.table, idx.columns))
+
+	return err
+}
@@ -0,0 +1,87 @@
+package pyt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validator is implemented by properties that check themselves before they
+// are written. Create, Upsert, and Update call Validate on the properties of
+// every node and edge before any SQL is executed
+//
+// ex:
+//
+//	func (u User) Validate() error {
+//		if u.Username == "" {
+//			return errors.New("username is required")
+//		}
+//
+//		return nil
+//	}
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError identifies the node or edge whose properties failed
+// validation. Index is its position in the written batch
+type ValidationError struct {
+	Index int
+	ID    string
+	Type  string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf(`invalid %s %s at %d: %v`, e.Type, e.ID, e.Index, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validateProperties calls Validate on the properties when they, or a
+// pointer to them, implement Validator
+func validateProperties[T any](index int, id, entityType string, properties T) error {
+	validator, ok := any(properties).(Validator)
+	if !ok {
+		validator, ok = any(&properties).(Validator)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	err := validator.Validate()
+	if err == nil {
+		return nil
+	}
+
+	return &ValidationError{
+		Index: index,
+		ID:    id,
+		Type:  entityType,
+		Err:   err,
+	}
+}
+
+// validate returns a ValidationError for each node that fails validation
+func (s NodeStore[T]) validate(nodes ...Node[T]) error {
+	errs := []error{}
+
+	for i, node := range nodes {
+		errs = append(errs, validateProperties(i, node.ID, s.nodeType(node), node.Properties))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate returns a ValidationError for each edge that fails validation
+func (s EdgeStore[T]) validate(edges ...Edge[T]) error {
+	errs := []error{}
+
+	for i, edge := range edges {
+		errs = append(errs, validateProperties(i, edge.ID, s.edgeType(edge), edge.Properties))
+	}
+
+	return errors.Join(errs...)
+}